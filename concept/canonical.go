@@ -0,0 +1,82 @@
+package concept
+
+import (
+	"fmt"
+	"strings"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+)
+
+// AnyType registers canonical validators which are run on concepts of every type.
+const AnyType = "*"
+
+// CanonicalValidator checks an invariant of canonical concepts, returning a description of each way the concept breaks it.
+type CanonicalValidator func(concept ontology.CanonicalConcept) []string
+
+// CanonicalValidators holds the validators run on canonical concepts before they are written, by concept type.
+type CanonicalValidators map[string][]CanonicalValidator
+
+// DefaultCanonicalValidators returns the validators enforcing the invariants the writers rely on.
+func DefaultCanonicalValidators() CanonicalValidators {
+	validators := CanonicalValidators{}
+	validators.Register(AnyType, RequirePrefLabel)
+	validators.Register("Membership", RequireRelationship("HAS_MEMBER"))
+	validators.Register("FinancialInstrument", RequireIssuedBy)
+	return validators
+}
+
+// Register adds validators for canonical concepts of the given type, or of every type for AnyType.
+func (v CanonicalValidators) Register(conceptType string, validators ...CanonicalValidator) {
+	v[conceptType] = append(v[conceptType], validators...)
+}
+
+// Validate runs the validators of the concept's type and the ones for every type, returning all the violations found.
+func (v CanonicalValidators) Validate(concept ontology.CanonicalConcept) []string {
+	var violations []string
+	for _, validator := range v[AnyType] {
+		violations = append(violations, validator(concept)...)
+	}
+	for _, validator := range v[concept.Type] {
+		violations = append(violations, validator(concept)...)
+	}
+	return violations
+}
+
+// RequirePrefLabel checks that the concept has a prefLabel.
+func RequirePrefLabel(concept ontology.CanonicalConcept) []string {
+	if strings.TrimSpace(concept.PrefLabel) == "" {
+		return []string{"prefLabel is empty"}
+	}
+	return nil
+}
+
+// RequireIssuedBy checks that the concept has the UUID of its issuing organisation.
+func RequireIssuedBy(concept ontology.CanonicalConcept) []string {
+	if concept.IssuedBy == "" {
+		return []string{fmt.Sprintf("%s has no issuedBy", concept.Type)}
+	}
+	return nil
+}
+
+// RequireRelationship returns a validator checking that the concept has at least one relationship with the given label.
+func RequireRelationship(label string) CanonicalValidator {
+	return func(concept ontology.CanonicalConcept) []string {
+		for _, rel := range concept.Relationships {
+			if rel.Label == label && rel.UUID != "" {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s has no %s relationship", concept.Type, label)}
+	}
+}
+
+// CanonicalViolationsError is returned when a canonical concept is not written because it breaks the invariants of its type.
+type CanonicalViolationsError struct {
+	UUID       string
+	Type       string
+	Violations []string
+}
+
+func (e *CanonicalViolationsError) Error() string {
+	return fmt.Sprintf("canonical concept %s of type %s is invalid: %s", e.UUID, e.Type, strings.Join(e.Violations, "; "))
+}
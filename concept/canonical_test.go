@@ -0,0 +1,84 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+func TestCanonicalValidators_Validate(t *testing.T) {
+	testCases := map[string]struct {
+		concept            ontology.CanonicalConcept
+		expectedViolations []string
+	}{
+		"Valid concept": {
+			concept: ontology.CanonicalConcept{PrefUUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Person", Type: "Person"},
+		},
+		"Empty prefLabel": {
+			concept:            ontology.CanonicalConcept{PrefUUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: " ", Type: "Person"},
+			expectedViolations: []string{"prefLabel is empty"},
+		},
+		"Valid membership": {
+			concept: ontology.CanonicalConcept{
+				PrefUUID:      "ce922022-8114-11e8-8f42-da24cd01f044",
+				PrefLabel:     "Membership",
+				Type:          "Membership",
+				Relationships: []ontology.Relationship{{UUID: "3b961db6-02c1-4fde-b96d-aefd339a02a6", Label: "HAS_MEMBER"}},
+			},
+		},
+		"Membership without member": {
+			concept: ontology.CanonicalConcept{
+				PrefUUID:      "ce922022-8114-11e8-8f42-da24cd01f044",
+				Type:          "Membership",
+				Relationships: []ontology.Relationship{{UUID: "3b961db6-02c1-4fde-b96d-aefd339a02a6", Label: "HAS_ORGANISATION"}},
+			},
+			expectedViolations: []string{"prefLabel is empty", "Membership has no HAS_MEMBER relationship"},
+		},
+		"Financial instrument without issuer": {
+			concept:            ontology.CanonicalConcept{PrefUUID: "6562674e-dbfa-4cb0-85b2-41b0948b7cc2", PrefLabel: "Instrument", Type: "FinancialInstrument"},
+			expectedViolations: []string{"FinancialInstrument has no issuedBy"},
+		},
+	}
+
+	validators := DefaultCanonicalValidators()
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedViolations, validators.Validate(tc.concept))
+		})
+	}
+}
+
+func TestCanonicalValidators_Register(t *testing.T) {
+	validators := CanonicalValidators{}
+	validators.Register("Brand", RequirePrefLabel, func(concept ontology.CanonicalConcept) []string {
+		if len(concept.Aliases) == 0 {
+			return []string{"Brand has no aliases"}
+		}
+		return nil
+	})
+
+	assert.Equal(t, []string{"prefLabel is empty", "Brand has no aliases"}, validators.Validate(ontology.CanonicalConcept{Type: "Brand"}))
+	assert.Empty(t, validators.Validate(ontology.CanonicalConcept{Type: "Person"}), "validators only run on their own type")
+}
+
+func TestAggregateService_ProcessMessage_InvalidCanonicalConceptNotWritten(t *testing.T) {
+	svc, _, _, eventQueue, _, _, _ := setupTestService(200, payload)
+	svc.canonicalValidators.Register(AnyType, func(concept ontology.CanonicalConcept) []string {
+		return []string{"concept is not allowed"}
+	})
+
+	err := svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	assert.Equal(t, failure.Validation, failure.KindOf(err))
+	var violations *CanonicalViolationsError
+	require.True(t, errors.As(err, &violations))
+	assert.Equal(t, []string{"concept is not allowed"}, violations.Violations)
+	assert.Empty(t, svc.httpClient.(*mockHTTPClient).called, "nothing should be written")
+	assert.Empty(t, eventQueue.eventList)
+}
@@ -18,8 +18,8 @@ func (d *mockConcordancesClient) GetConcordance(ctx context.Context, uuid string
 	}
 	return []concordances.ConcordanceRecord{
 		{
-			UUID:      uuid,
-			Authority: "Smartlogic",
+			UUID: uuid,
+			Solo: true,
 		},
 	}, d.err
 }
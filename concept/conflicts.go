@@ -0,0 +1,125 @@
+package concept
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+// ConcordanceConflict is a concordance cluster which cannot be aggregated because it has more than one record of a primary authority.
+// Concepts are the UUIDs whose aggregation found the conflict, and Count is how many times it was found.
+type ConcordanceConflict struct {
+	Concepts    []string                         `json:"concepts"`
+	Authorities []string                         `json:"authorities"`
+	Records     []concordances.ConcordanceRecord `json:"records"`
+	FirstSeen   time.Time                        `json:"firstSeen"`
+	LastSeen    time.Time                        `json:"lastSeen"`
+	Count       int                              `json:"count"`
+}
+
+// conflictStore keeps the concordance conflicts found since the service started, until a concept of the cluster is aggregated again
+// without conflict. A conflict is identified by its records, so it is only listed once whichever concept of the cluster found it.
+type conflictStore struct {
+	mu        sync.Mutex
+	conflicts map[string]*ConcordanceConflict
+	now       func() time.Time
+}
+
+func newConflictStore() *conflictStore {
+	return &conflictStore{conflicts: map[string]*ConcordanceConflict{}, now: time.Now}
+}
+
+func conflictKey(records []concordances.ConcordanceRecord) string {
+	uuids := make([]string, 0, len(records))
+	for _, record := range records {
+		uuids = append(uuids, record.UUID)
+	}
+	sort.Strings(uuids)
+	return strings.Join(uuids, ",")
+}
+
+// record adds a conflict found while aggregating the concept with the given UUID.
+func (s *conflictStore) record(UUID string, conflict *PrimaryAuthorityConflictError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	key := conflictKey(conflict.Records)
+	c, ok := s.conflicts[key]
+	if !ok {
+		c = &ConcordanceConflict{FirstSeen: now}
+		s.conflicts[key] = c
+	}
+	if !contains(UUID, c.Concepts) {
+		c.Concepts = append(c.Concepts, UUID)
+	}
+	c.Authorities = append([]string(nil), conflict.Authorities...)
+	c.Records = append([]concordances.ConcordanceRecord(nil), conflict.Records...)
+	c.LastSeen = now
+	c.Count++
+}
+
+// resolve removes the conflicts of the concept with the given UUID, once it has been aggregated without conflict.
+func (s *conflictStore) resolve(UUID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, c := range s.conflicts {
+		if contains(UUID, c.Concepts) || strings.Contains(key, UUID) {
+			delete(s.conflicts, key)
+		}
+	}
+}
+
+// list returns the conflicts, the most recently seen first.
+func (s *conflictStore) list() []ConcordanceConflict {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conflicts := make([]ConcordanceConflict, 0, len(s.conflicts))
+	for _, c := range s.conflicts {
+		conflict := *c
+		conflict.Concepts = append([]string(nil), c.Concepts...)
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if !conflicts[i].LastSeen.Equal(conflicts[j].LastSeen) {
+			return conflicts[i].LastSeen.After(conflicts[j].LastSeen)
+		}
+		return conflictKey(conflicts[i].Records) < conflictKey(conflicts[j].Records)
+	})
+	return conflicts
+}
+
+// writeConflictsCSV writes one line per conflict, with the records as space separated authority:UUID pairs.
+func writeConflictsCSV(w io.Writer, conflicts []ConcordanceConflict) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"concepts", "authorities", "records", "firstSeen", "lastSeen", "count"}); err != nil {
+		return err
+	}
+	for _, c := range conflicts {
+		records := make([]string, 0, len(c.Records))
+		for _, record := range c.Records {
+			records = append(records, record.Authority+":"+record.UUID)
+		}
+		err := out.Write([]string{
+			strings.Join(c.Concepts, " "),
+			strings.Join(c.Authorities, " "),
+			strings.Join(records, " "),
+			c.FirstSeen.Format(time.RFC3339),
+			c.LastSeen.Format(time.RFC3339),
+			strconv.Itoa(c.Count),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,136 @@
+package concept
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+var conflictingRecords = []concordances.ConcordanceRecord{
+	{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "Smartlogic"},
+	{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+}
+
+func TestConflictStore(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := newConflictStore()
+	store.now = func() time.Time { return now }
+	conflict := &PrimaryAuthorityConflictError{Authorities: []string{"Smartlogic"}, Records: conflictingRecords}
+
+	store.record("28090964-9997-4bc2-9638-7a11135aaff9", conflict)
+	now = now.Add(time.Hour)
+	store.record("34a571fb-d779-4610-a7ba-2e127676db4d", conflict)
+	store.record("34a571fb-d779-4610-a7ba-2e127676db4d", conflict)
+
+	other := &PrimaryAuthorityConflictError{
+		Authorities: []string{"ManagedLocation"},
+		Records: []concordances.ConcordanceRecord{
+			{UUID: "5b4a0a28-8f1d-4a3e-9d2f-0f6c3a0c9b01", Authority: "ManagedLocation"},
+			{UUID: "6c5b1b39-9a2e-4b4f-8e3a-1a7d4b1dac12", Authority: "ManagedLocation"},
+		},
+	}
+	store.record("5b4a0a28-8f1d-4a3e-9d2f-0f6c3a0c9b01", other)
+
+	conflicts := store.list()
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, ConcordanceConflict{
+		Concepts:    []string{"28090964-9997-4bc2-9638-7a11135aaff9", "34a571fb-d779-4610-a7ba-2e127676db4d"},
+		Authorities: []string{"Smartlogic"},
+		Records:     conflictingRecords,
+		FirstSeen:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		LastSeen:    time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC),
+		Count:       3,
+	}, conflicts[0], "a conflict should be listed once whichever concept of the cluster found it")
+	assert.Equal(t, []string{"ManagedLocation"}, conflicts[1].Authorities)
+
+	store.resolve("6c5b1b39-9a2e-4b4f-8e3a-1a7d4b1dac12")
+	conflicts = store.list()
+	require.Len(t, conflicts, 1, "a conflict should be resolved by aggregating any concept of the cluster")
+	assert.Equal(t, []string{"Smartlogic"}, conflicts[0].Authorities)
+}
+
+func TestWriteConflictsCSV(t *testing.T) {
+	var b bytes.Buffer
+	err := writeConflictsCSV(&b, []ConcordanceConflict{{
+		Concepts:    []string{"28090964-9997-4bc2-9638-7a11135aaff9"},
+		Authorities: []string{"Smartlogic"},
+		Records:     conflictingRecords,
+		FirstSeen:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		LastSeen:    time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC),
+		Count:       2,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "concepts,authorities,records,firstSeen,lastSeen,count\n"+
+		"28090964-9997-4bc2-9638-7a11135aaff9,Smartlogic,Smartlogic:34a571fb-d779-4610-a7ba-2e127676db4d Smartlogic:28090964-9997-4bc2-9638-7a11135aaff9,2024-03-01T12:00:00Z,2024-03-01T13:00:00Z,2\n",
+		b.String())
+}
+
+func TestAggregateService_Conflicts(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	clusters := &mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{"28090964-9997-4bc2-9638-7a11135aaff9": conflictingRecords},
+	}
+	svc.concordances = clusters
+
+	_, _, err := svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	assert.EqualError(t, err, "more than 1 primary authority")
+	conflicts := svc.Conflicts()
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, conflictingRecords, conflicts[0].Records)
+	assert.Equal(t, 1, conflicts[0].Count)
+
+	// the conflict is fixed in the concordance store
+	delete(clusters.concordances, "28090964-9997-4bc2-9638-7a11135aaff9")
+	_, _, err = svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	assert.NoError(t, err)
+	assert.Empty(t, svc.Conflicts())
+}
+
+func TestConflictsHandler(t *testing.T) {
+	conflicts := []ConcordanceConflict{{
+		Concepts:    []string{"28090964-9997-4bc2-9638-7a11135aaff9"},
+		Authorities: []string{"Smartlogic"},
+		Records:     conflictingRecords,
+		FirstSeen:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		LastSeen:    time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC),
+		Count:       2,
+	}}
+	testCases := map[string]struct {
+		url                 string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		"JSON":           {url: "/__conflicts", expectedStatus: http.StatusOK, expectedContentType: "application/json"},
+		"CSV":            {url: "/__conflicts?format=csv", expectedStatus: http.StatusOK, expectedContentType: "text/csv"},
+		"Unknown format": {url: "/__conflicts?format=xml", expectedStatus: http.StatusBadRequest, expectedContentType: "application/json"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mockService := NewMockService(nil, nil, nil, nil)
+			mockService.conflicts = conflicts
+			handler := NewHandler(mockService, time.Second)
+			sm := handler.RegisterHandlers(NewHealthService(mockService, "system-code", "app-name", 8080, "description"), false, make(chan bool))
+
+			rr := httptest.NewRecorder()
+			sm.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"))
+			if name == "JSON" {
+				var actual []ConcordanceConflict
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&actual))
+				assert.Equal(t, conflicts, actual)
+			}
+		})
+	}
+}
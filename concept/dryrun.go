@@ -0,0 +1,242 @@
+package concept
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+	"github.com/Financial-Times/aggregate-concept-transformer/sns"
+)
+
+// FieldChange is a field of the canonical concept whose value would change. Field is a JSON pointer to it,
+// and Old or New is missing when the field would be added or removed.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// KinesisRecord is a notification of updated concepts which would be added to the Kinesis stream.
+type KinesisRecord struct {
+	ConceptType string   `json:"conceptType"`
+	UUIDs       []string `json:"uuids"`
+}
+
+// EstimatedEffects are the purges, events and Kinesis records sending a concept is expected to produce.
+// They are estimated from the diff, as the Neo4j writer only reports the changed records once it has written the concept,
+// so they can differ from what a real update does.
+type EstimatedEffects struct {
+	Purges         []string        `json:"purges"`
+	Events         []sns.Event     `json:"events"`
+	KinesisRecords []KinesisRecord `json:"kinesisRecords"`
+}
+
+// WritePlan describes what sending a concept to the writers would do, compared to the concept currently stored in Neo4j.
+// Skipped is the reason the concept would not be sent at all, e.g. because its content hash has not changed.
+type WritePlan struct {
+	UUID          string           `json:"uuid"`
+	TransactionID string           `json:"transactionID"`
+	Type          string           `json:"type"`
+	Stored        bool             `json:"stored"`
+	Diff          []FieldChange    `json:"diff"`
+	Violations    []string         `json:"violations,omitempty"`
+	Skipped       string           `json:"skipped,omitempty"`
+	Writers       []string         `json:"writers"`
+	Estimated     EstimatedEffects `json:"estimated"`
+}
+
+// skippedUnchanged is the reason given in a plan for a concept whose content hash is the one of the last concept written.
+const skippedUnchanged = "content unchanged"
+
+// PlanConcept aggregates a concept and works out what sending it would do, without writing anything.
+// As when sending it, a concept with the content hash of the last concept written is skipped unless force is set.
+// It can be used when the service is in read-only mode.
+func (s *AggregateService) PlanConcept(ctx context.Context, UUID string, force bool) (WritePlan, error) {
+	concordedConcept, transactionID, err := s.GetConcordedConcept(ctx, UUID, "")
+	if err != nil {
+		return WritePlan{}, err
+	}
+	plan := WritePlan{
+		UUID:          concordedConcept.PrefUUID,
+		TransactionID: transactionID,
+		Type:          concordedConcept.Type,
+		Diff:          []FieldChange{},
+		Writers:       []string{},
+		Estimated: EstimatedEffects{
+			Purges:         []string{},
+			Events:         []sns.Event{},
+			KinesisRecords: []KinesisRecord{},
+		},
+	}
+
+	stored, found, err := s.getStoredConcept(ctx, concordedConcept, transactionID)
+	if err != nil {
+		return WritePlan{}, err
+	}
+	plan.Stored = found
+	if plan.Diff, err = diffConcepts(stored, concordedConcept); err != nil {
+		return WritePlan{}, err
+	}
+
+	if plan.Violations = s.canonicalValidators.Validate(concordedConcept); len(plan.Violations) > 0 {
+		// the concept would be rejected before reaching the writers
+		return plan, nil
+	}
+	if _, unchanged := s.checkContentHash(ctx, concordedConcept, transactionID); unchanged && !force {
+		plan.Skipped = skippedUnchanged
+		return plan, nil
+	}
+	plan.Writers = append(plan.Writers, writerURL(s.neoWriterAddress, concordedConcept))
+	if len(plan.Diff) == 0 {
+		// the Neo4j writer would report no changes, so nothing else would happen
+		return plan, nil
+	}
+
+	updatedIDs := []string{concordedConcept.PrefUUID}
+	var addedIDs, removedIDs []string
+	current := sourceUUIDs(concordedConcept)
+	previous := sourceUUIDs(stored)
+	for _, id := range current {
+		if id != concordedConcept.PrefUUID {
+			updatedIDs = append(updatedIDs, id)
+		}
+		if found && !contains(id, previous) {
+			addedIDs = append(addedIDs, id)
+		}
+	}
+	for _, id := range previous {
+		if !contains(id, current) && id != concordedConcept.PrefUUID {
+			updatedIDs = append(updatedIDs, id)
+			removedIDs = append(removedIDs, id)
+		}
+	}
+
+	plan.Estimated.Purges = append(plan.Estimated.Purges, purgeTargets(updatedIDs, concordedConcept.Type, s.typesToPurgeFromPublicEndpoints)...)
+	relatedPurges, err := s.purgeRules.relatedPurges(concordedConcept)
+	if err != nil {
+		return WritePlan{}, err
+	}
+	for _, purge := range relatedPurges {
+		if len(purge.UUIDs) > 0 {
+			plan.Estimated.Purges = append(plan.Estimated.Purges, purgeTargets(purge.UUIDs, purge.Type, s.typesToPurgeFromPublicEndpoints)...)
+		}
+	}
+
+	if s.allowedInElasticsearch(concordedConcept) {
+		plan.Writers = append(plan.Writers, writerURL(s.elasticsearchWriterAddress, concordedConcept))
+	}
+
+	newEvent := func(details map[string]string) sns.Event {
+		return sns.Event{ConceptType: concordedConcept.Type, ConceptUUID: concordedConcept.PrefUUID, TransactionID: transactionID, EventDetails: details}
+	}
+	plan.Estimated.Events = append(plan.Estimated.Events, newEvent(map[string]string{"type": "Concept Updated"}))
+	for _, id := range addedIDs {
+		plan.Estimated.Events = append(plan.Estimated.Events, newEvent(map[string]string{"type": "Concordance Added", "oldID": id, "newID": concordedConcept.PrefUUID}))
+	}
+	for _, id := range removedIDs {
+		plan.Estimated.Events = append(plan.Estimated.Events, newEvent(map[string]string{"type": "Concordance Removed", "oldID": concordedConcept.PrefUUID, "newID": id}))
+	}
+
+	plan.Estimated.KinesisRecords = append(plan.Estimated.KinesisRecords, KinesisRecord{ConceptType: concordedConcept.Type, UUIDs: updatedIDs})
+	return plan, nil
+}
+
+func writerURL(baseURL string, concept ontology.CanonicalConcept) string {
+	return strings.TrimRight(baseURL, "/") + "/" + resolveConceptType(concept.Type) + "/" + concept.PrefUUID
+}
+
+func sourceUUIDs(concept ontology.CanonicalConcept) []string {
+	var uuids []string
+	for _, source := range concept.SourceRepresentations {
+		uuids = append(uuids, source.UUID)
+	}
+	return uuids
+}
+
+// getStoredConcept reads the concept currently stored in Neo4j from the Neo4j writer, reporting whether there is one.
+func (s *AggregateService) getStoredConcept(ctx context.Context, concept ontology.CanonicalConcept, transactionID string) (ontology.CanonicalConcept, bool, error) {
+	reqURL := writerURL(s.neoWriterAddress, concept)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return ontology.CanonicalConcept{}, false, err
+	}
+	req.Header.Set("X-Request-Id", transactionID)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return ontology.CanonicalConcept{}, false, failure.FromTransport(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ontology.CanonicalConcept{}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ontology.CanonicalConcept{}, false, failure.FromStatus(resp.StatusCode, fmt.Errorf("request to %s returned status: %d", reqURL, resp.StatusCode))
+	}
+	var stored ontology.CanonicalConcept
+	if err = json.NewDecoder(resp.Body).Decode(&stored); err != nil && !errors.Is(err, io.EOF) {
+		return ontology.CanonicalConcept{}, false, fmt.Errorf("decoding concept from %s: %w", reqURL, err)
+	}
+	return stored, true, nil
+}
+
+// diffConcepts compares the JSON documents of the concepts field by field, going down into objects but comparing arrays as a whole.
+func diffConcepts(old ontology.CanonicalConcept, new ontology.CanonicalConcept) ([]FieldChange, error) {
+	oldDoc, err := toJSONDocument(old)
+	if err != nil {
+		return nil, err
+	}
+	newDoc, err := toJSONDocument(new)
+	if err != nil {
+		return nil, err
+	}
+	return diffJSON("", oldDoc, newDoc), nil
+}
+
+func toJSONDocument(concept ontology.CanonicalConcept) (map[string]interface{}, error) {
+	b, err := json.Marshal(concept)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	err = json.Unmarshal(b, &doc)
+	return doc, err
+}
+
+func diffJSON(path string, old interface{}, new interface{}) []FieldChange {
+	oldObject, oldIsObject := old.(map[string]interface{})
+	newObject, newIsObject := new.(map[string]interface{})
+	if !oldIsObject || !newIsObject {
+		if reflect.DeepEqual(old, new) {
+			return nil
+		}
+		return []FieldChange{{Field: path, Old: old, New: new}}
+	}
+
+	keys := make([]string, 0, len(oldObject)+len(newObject))
+	for key := range oldObject {
+		keys = append(keys, key)
+	}
+	for key := range newObject {
+		if _, ok := oldObject[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []FieldChange{}
+	for _, key := range keys {
+		pointer := path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+		changes = append(changes, diffJSON(pointer, oldObject[key], newObject[key])...)
+	}
+	return changes
+}
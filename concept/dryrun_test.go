@@ -0,0 +1,193 @@
+package concept
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/sns"
+)
+
+type storedResponse struct {
+	status int
+	body   string
+}
+
+// storedConceptClient serves the concepts stored by the writers and records every request made to it.
+type storedConceptClient struct {
+	sync.Mutex
+	responses map[string]storedResponse
+	requests  []string
+}
+
+func (c *storedConceptClient) Do(req *http.Request) (*http.Response, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.requests = append(c.requests, req.Method+" "+req.URL.String())
+	resp, ok := c.responses[req.URL.String()]
+	if !ok {
+		resp = storedResponse{status: http.StatusNotFound}
+	}
+	return &http.Response{StatusCode: resp.status, Body: io.NopCloser(strings.NewReader(resp.body))}, nil
+}
+
+func setupDryRunTest(storedConcept string) (*AggregateService, *storedConceptClient) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.concordances = &mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+				{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", AuthorityValue: "TME-1"},
+			},
+		},
+	}
+	svc.nStore = &versionedS3Client{
+		versions: map[string][]conceptVersion{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				{transactionID: "tid_sl", concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "New label", Authority: "Smartlogic", Type: "Person"}},
+			},
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				{transactionID: "tid_tme", concept: ontology.SourceConcept{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", PrefLabel: "TME label", Authority: "FT-TME", Type: "Person"}},
+			},
+		},
+	}
+	client := &storedConceptClient{responses: map[string]storedResponse{}}
+	if storedConcept != "" {
+		client.responses["concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9"] = storedResponse{status: http.StatusOK, body: storedConcept}
+	}
+	svc.httpClient = client
+	return svc, client
+}
+
+func TestAggregateService_PlanConcept(t *testing.T) {
+	svc, client := setupDryRunTest(`{
+		"prefUUID": "28090964-9997-4bc2-9638-7a11135aaff9",
+		"prefLabel": "Old label",
+		"type": "Person",
+		"sourceRepresentations": [
+			{"uuid": "28090964-9997-4bc2-9638-7a11135aaff9", "prefLabel": "Old label", "type": "Person", "authority": "Smartlogic"},
+			{"uuid": "0e2d5e1a-3b4f-4c1a-9f0e-5d2b7c8a9e10", "prefLabel": "Removed", "type": "Person", "authority": "FACTSET"}
+		]
+	}`)
+
+	plan, err := svc.PlanConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "28090964-9997-4bc2-9638-7a11135aaff9", plan.UUID)
+	assert.Equal(t, "tid_sl", plan.TransactionID)
+	assert.True(t, plan.Stored)
+	assert.Contains(t, plan.Diff, FieldChange{Field: "/prefLabel", Old: "Old label", New: "New label"})
+	for _, change := range plan.Diff {
+		assert.NotEqual(t, "/type", change.Field, "unchanged fields should not be in the diff")
+	}
+	assert.Empty(t, plan.Violations)
+	assert.Equal(t, []string{
+		"concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9",
+		"concept-rw-elasticsearch/people/28090964-9997-4bc2-9638-7a11135aaff9",
+	}, plan.Writers)
+	assert.Contains(t, plan.Estimated.Purges, "/things/34a571fb-d779-4610-a7ba-2e127676db4d")
+	assert.Contains(t, plan.Estimated.Purges, "/people/0e2d5e1a-3b4f-4c1a-9f0e-5d2b7c8a9e10")
+	assert.Equal(t, []sns.Event{
+		{ConceptType: "Person", ConceptUUID: "28090964-9997-4bc2-9638-7a11135aaff9", TransactionID: "tid_sl", EventDetails: map[string]string{"type": "Concept Updated"}},
+		{ConceptType: "Person", ConceptUUID: "28090964-9997-4bc2-9638-7a11135aaff9", TransactionID: "tid_sl", EventDetails: map[string]string{"type": "Concordance Added", "oldID": "34a571fb-d779-4610-a7ba-2e127676db4d", "newID": "28090964-9997-4bc2-9638-7a11135aaff9"}},
+		{ConceptType: "Person", ConceptUUID: "28090964-9997-4bc2-9638-7a11135aaff9", TransactionID: "tid_sl", EventDetails: map[string]string{"type": "Concordance Removed", "oldID": "28090964-9997-4bc2-9638-7a11135aaff9", "newID": "0e2d5e1a-3b4f-4c1a-9f0e-5d2b7c8a9e10"}},
+	}, plan.Estimated.Events)
+	assert.Equal(t, []KinesisRecord{{
+		ConceptType: "Person",
+		UUIDs:       []string{"28090964-9997-4bc2-9638-7a11135aaff9", "34a571fb-d779-4610-a7ba-2e127676db4d", "0e2d5e1a-3b4f-4c1a-9f0e-5d2b7c8a9e10"},
+	}}, plan.Estimated.KinesisRecords)
+
+	assert.Equal(t, []string{"GET concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9"}, client.requests, "a dry run should not write anything")
+}
+
+func TestAggregateService_PlanConcept_Unchanged(t *testing.T) {
+	svc, _ := setupDryRunTest("")
+	concept, _, err := svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	require.NoError(t, err)
+	stored, err := json.Marshal(concept)
+	require.NoError(t, err)
+	svc, _ = setupDryRunTest(string(stored))
+
+	plan, err := svc.PlanConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", false)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Diff)
+	assert.Equal(t, []string{"concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9"}, plan.Writers)
+	assert.Empty(t, plan.Estimated.Purges)
+	assert.Empty(t, plan.Estimated.Events)
+	assert.Empty(t, plan.Estimated.KinesisRecords)
+}
+
+func TestAggregateService_PlanConcept_NotStoredInReadOnlyMode(t *testing.T) {
+	svc, _ := setupDryRunTest("")
+	svc.readOnly = true
+
+	plan, err := svc.PlanConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", false)
+	require.NoError(t, err)
+	assert.False(t, plan.Stored)
+	assert.Contains(t, plan.Diff, FieldChange{Field: "/prefLabel", New: "New label"})
+	assert.Len(t, plan.Estimated.Events, 1, "a new concept should not have concordance events")
+}
+
+func TestAggregateService_PlanConcept_Violations(t *testing.T) {
+	svc, _ := setupDryRunTest("")
+	svc.canonicalValidators = CanonicalValidators{AnyType: {func(ontology.CanonicalConcept) []string { return []string{"always invalid"} }}}
+
+	plan, err := svc.PlanConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"always invalid"}, plan.Violations)
+	assert.Empty(t, plan.Writers, "an invalid concept should not reach the writers")
+}
+
+func TestAggregateService_PlanConcept_UnchangedContentHash(t *testing.T) {
+	svc, _ := setupDryRunTest("")
+	svc.contentHashes = NewMemoryHashStore(10)
+	concept, _, err := svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	require.NoError(t, err)
+	hash, _ := svc.checkContentHash(context.Background(), concept, "tid_sl")
+	svc.storeContentHash(context.Background(), concept.PrefUUID, hash, "tid_sl")
+
+	plan, err := svc.PlanConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", false)
+	require.NoError(t, err)
+	assert.Equal(t, "content unchanged", plan.Skipped)
+	assert.NotEmpty(t, plan.Diff, "the diff should still show how the concept differs from the one in Neo4j")
+	assert.Empty(t, plan.Writers, "an unchanged concept should not be sent")
+	assert.Empty(t, plan.Estimated.Events)
+
+	plan, err = svc.PlanConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", true)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Skipped)
+	assert.Equal(t, []string{
+		"concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9",
+		"concept-rw-elasticsearch/people/28090964-9997-4bc2-9638-7a11135aaff9",
+	}, plan.Writers, "a forced update should be sent even when unchanged")
+}
+
+func TestAggregateService_PlanConcept_WriterError(t *testing.T) {
+	svc, client := setupDryRunTest("")
+	client.responses["concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9"] = storedResponse{status: http.StatusServiceUnavailable}
+
+	_, err := svc.PlanConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", false)
+	assert.EqualError(t, err, "request to concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9 returned status: 503")
+}
+
+func TestDiffJSON(t *testing.T) {
+	old := map[string]interface{}{"a/b": "x", "nested": map[string]interface{}{"kept": 1.0, "changed": "old"}, "list": []interface{}{"a"}, "removed": true}
+	new := map[string]interface{}{"a/b": "y", "nested": map[string]interface{}{"kept": 1.0, "changed": "new"}, "list": []interface{}{"a", "b"}}
+
+	assert.Equal(t, []FieldChange{
+		{Field: "/a~1b", Old: "x", New: "y"},
+		{Field: "/list", Old: []interface{}{"a"}, New: []interface{}{"a", "b"}},
+		{Field: "/nested/changed", Old: "old", New: "new"},
+		{Field: "/removed", Old: true},
+	}, diffJSON("", old, new))
+}
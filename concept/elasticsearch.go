@@ -0,0 +1,248 @@
+package concept
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/go-logger"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+)
+
+// ElasticsearchAction tells whether concepts are sent to Elasticsearch.
+type ElasticsearchAction string
+
+const (
+	ElasticsearchAllow ElasticsearchAction = "allow"
+	ElasticsearchDeny  ElasticsearchAction = "deny"
+)
+
+// ElasticsearchRule allows or denies sending concepts of a type to Elasticsearch.
+// A rule with authorities only applies to concepts with a source from one of them.
+type ElasticsearchRule struct {
+	Type        string              `json:"type"`
+	Action      ElasticsearchAction `json:"action"`
+	Authorities []string            `json:"authorities,omitempty"`
+}
+
+// ElasticsearchPolicy decides which concepts are sent to Elasticsearch. The first rule which applies to a concept is used,
+// and the default action when none does.
+type ElasticsearchPolicy struct {
+	Default ElasticsearchAction `json:"default"`
+	Rules   []ElasticsearchRule `json:"rules"`
+}
+
+// DefaultElasticsearchPolicy returns the policy used when none is configured. Smartlogic memberships are let through
+// as they are used to discover authors.
+func DefaultElasticsearchPolicy() ElasticsearchPolicy {
+	return ElasticsearchPolicy{
+		Default: ElasticsearchAllow,
+		Rules: []ElasticsearchRule{
+			{Type: "FinancialInstrument", Action: ElasticsearchDeny},
+			{Type: "MembershipRole", Action: ElasticsearchDeny},
+			{Type: "BoardRole", Action: ElasticsearchDeny},
+			{Type: "Membership", Action: ElasticsearchAllow, Authorities: []string{ontology.SmartlogicAuthority}},
+			{Type: "Membership", Action: ElasticsearchDeny},
+			{Type: "IndustryClassification", Action: ElasticsearchDeny},
+			{Type: "NAICSIndustryClassification", Action: ElasticsearchDeny},
+			{Type: "FTAnIIndustryClassification", Action: ElasticsearchDeny},
+		},
+	}
+}
+
+// LoadElasticsearchPolicy reads the policy from a JSON file, falling back to the default policy when no file is given.
+func LoadElasticsearchPolicy(path string) (ElasticsearchPolicy, error) {
+	if path == "" {
+		return DefaultElasticsearchPolicy(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ElasticsearchPolicy{}, err
+	}
+	defer f.Close()
+
+	var policy ElasticsearchPolicy
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&policy); err != nil {
+		return ElasticsearchPolicy{}, fmt.Errorf("decoding Elasticsearch policy from %s: %w", path, err)
+	}
+	if err = policy.validate(); err != nil {
+		return ElasticsearchPolicy{}, fmt.Errorf("invalid Elasticsearch policy in %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+func validAction(action ElasticsearchAction) bool {
+	return action == ElasticsearchAllow || action == ElasticsearchDeny
+}
+
+func (p *ElasticsearchPolicy) validate() error {
+	if p.Default == "" {
+		p.Default = ElasticsearchAllow
+	}
+	if !validAction(p.Default) {
+		return fmt.Errorf("unknown default action %q", p.Default)
+	}
+	for i, rule := range p.Rules {
+		if rule.Type == "" {
+			return fmt.Errorf("rule %d has no type", i)
+		}
+		if !validAction(rule.Action) {
+			return fmt.Errorf("unknown action %q for type %s", rule.Action, rule.Type)
+		}
+	}
+	return nil
+}
+
+// allows tells whether the concept is sent to Elasticsearch.
+func (p ElasticsearchPolicy) allows(concept ontology.CanonicalConcept) bool {
+	for _, rule := range p.Rules {
+		if rule.Type == concept.Type && rule.appliesTo(concept) {
+			return rule.Action == ElasticsearchAllow
+		}
+	}
+	return p.Default != ElasticsearchDeny
+}
+
+func (r ElasticsearchRule) appliesTo(concept ontology.CanonicalConcept) bool {
+	if len(r.Authorities) == 0 {
+		return true
+	}
+	for _, source := range concept.SourceRepresentations {
+		if contains(source.Authority, r.Authorities) {
+			return true
+		}
+	}
+	return false
+}
+
+// ElasticsearchPolicyStatus reports the Elasticsearch policy in use and where it was loaded from.
+// Error is the reason the last reload failed, in which case the previous policy is still used.
+type ElasticsearchPolicyStatus struct {
+	Path     string              `json:"path,omitempty"`
+	Policy   ElasticsearchPolicy `json:"policy"`
+	LoadedAt time.Time           `json:"loadedAt"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// ElasticsearchPolicySource holds the Elasticsearch policy in use, which can be reloaded from its file while the service runs.
+type ElasticsearchPolicySource struct {
+	path     string
+	mu       sync.RWMutex
+	policy   ElasticsearchPolicy
+	loadedAt time.Time
+	modTime  time.Time
+	err      error
+	now      func() time.Time
+}
+
+// NewElasticsearchPolicySource loads the policy from the file at path, or uses the default policy when there is no path.
+func NewElasticsearchPolicySource(path string) (*ElasticsearchPolicySource, error) {
+	s := &ElasticsearchPolicySource{path: path, now: time.Now}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Policy returns the policy in use.
+func (s *ElasticsearchPolicySource) Policy() ElasticsearchPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy
+}
+
+// Reload reads the policy from its file again. An invalid policy is reported and the previous one is kept.
+func (s *ElasticsearchPolicySource) Reload() error {
+	var modTime time.Time
+	if s.path != "" {
+		if info, err := os.Stat(s.path); err == nil {
+			modTime = info.ModTime()
+		}
+	}
+	policy, err := LoadElasticsearchPolicy(s.path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.modTime = modTime
+	s.err = err
+	if err != nil {
+		return err
+	}
+	s.policy = policy
+	s.loadedAt = s.now()
+	return nil
+}
+
+// Status reports the policy in use and the outcome of the last reload.
+func (s *ElasticsearchPolicySource) Status() ElasticsearchPolicyStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status := ElasticsearchPolicyStatus{Path: s.path, Policy: s.policy, LoadedAt: s.loadedAt}
+	if s.err != nil {
+		status.Error = s.err.Error()
+	}
+	return status
+}
+
+// Watch reloads the policy whenever its file is modified, checking every interval until the context is done.
+func (s *ElasticsearchPolicySource) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(s.path)
+		if err != nil {
+			logger.WithError(err).Warn("Error checking the Elasticsearch policy file")
+			continue
+		}
+		s.mu.RLock()
+		modified := !info.ModTime().Equal(s.modTime)
+		s.mu.RUnlock()
+		if !modified {
+			continue
+		}
+		if err = s.Reload(); err != nil {
+			logger.WithError(err).Error("Error reloading the Elasticsearch policy, the previous policy is still used")
+			continue
+		}
+		logger.Infof("Reloaded the Elasticsearch policy from %s, it applies to the concepts written from now on", s.path)
+	}
+}
+
+func (s *AggregateService) allowedInElasticsearch(concept ontology.CanonicalConcept) bool {
+	if s.elasticsearchPolicy == nil {
+		return DefaultElasticsearchPolicy().allows(concept)
+	}
+	return s.elasticsearchPolicy.Policy().allows(concept)
+}
+
+// ElasticsearchPolicy reports the policy deciding which concepts are sent to Elasticsearch.
+func (s *AggregateService) ElasticsearchPolicy() ElasticsearchPolicyStatus {
+	if s.elasticsearchPolicy == nil {
+		return ElasticsearchPolicyStatus{Policy: DefaultElasticsearchPolicy()}
+	}
+	return s.elasticsearchPolicy.Status()
+}
+
+// ReloadElasticsearchPolicy reads the Elasticsearch policy from its file again, keeping the previous policy when it is invalid.
+// The concepts already written are not re-evaluated against the new policy.
+func (s *AggregateService) ReloadElasticsearchPolicy() (ElasticsearchPolicyStatus, error) {
+	if s.elasticsearchPolicy == nil {
+		return s.ElasticsearchPolicy(), nil
+	}
+	err := s.elasticsearchPolicy.Reload()
+	return s.elasticsearchPolicy.Status(), err
+}
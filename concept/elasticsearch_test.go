@@ -0,0 +1,219 @@
+package concept
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+)
+
+func TestElasticsearchPolicy_Allows(t *testing.T) {
+	smartlogicSource := []ontology.SourceConcept{{UUID: "ce922022-8114-11e8-8f42-da24cd01f044", Authority: "Smartlogic"}}
+	factsetSource := []ontology.SourceConcept{{UUID: "ce922022-8114-11e8-8f42-da24cd01f044", Authority: "FACTSET"}}
+	onlyPeople := ElasticsearchPolicy{Default: ElasticsearchDeny, Rules: []ElasticsearchRule{{Type: "Person", Action: ElasticsearchAllow}}}
+
+	testCases := map[string]struct {
+		policy   ElasticsearchPolicy
+		concept  ontology.CanonicalConcept
+		expected bool
+	}{
+		"Person is sent":                          {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "Person"}, expected: true},
+		"FinancialInstrument is not sent":         {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "FinancialInstrument"}},
+		"MembershipRole is not sent":              {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "MembershipRole"}},
+		"BoardRole is not sent":                   {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "BoardRole"}},
+		"Smartlogic Membership is sent":           {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "Membership", SourceRepresentations: smartlogicSource}, expected: true},
+		"FACTSET Membership is not sent":          {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "Membership", SourceRepresentations: factsetSource}},
+		"IndustryClassification is not sent":      {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "IndustryClassification"}},
+		"NAICSIndustryClassification isn't sent":  {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "NAICSIndustryClassification"}},
+		"FTAnIIndustryClassification isn't sent":  {policy: DefaultElasticsearchPolicy(), concept: ontology.CanonicalConcept{Type: "FTAnIIndustryClassification"}},
+		"Default deny lets allowed types through": {policy: onlyPeople, concept: ontology.CanonicalConcept{Type: "Person"}, expected: true},
+		"Default deny stops other types":          {policy: onlyPeople, concept: ontology.CanonicalConcept{Type: "Organisation"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.policy.allows(tc.concept))
+		})
+	}
+}
+
+func writeElasticsearchPolicy(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestLoadElasticsearchPolicy(t *testing.T) {
+	testCases := map[string]struct {
+		content        string
+		expectedPolicy ElasticsearchPolicy
+		expectedErr    string
+	}{
+		"Valid policy": {
+			content: `{"default": "deny", "rules": [{"type": "Membership", "action": "allow", "authorities": ["Smartlogic"]}]}`,
+			expectedPolicy: ElasticsearchPolicy{
+				Default: ElasticsearchDeny,
+				Rules:   []ElasticsearchRule{{Type: "Membership", Action: ElasticsearchAllow, Authorities: []string{"Smartlogic"}}},
+			},
+		},
+		"Default action defaults to allow": {
+			content:        `{"rules": [{"type": "BoardRole", "action": "deny"}]}`,
+			expectedPolicy: ElasticsearchPolicy{Default: ElasticsearchAllow, Rules: []ElasticsearchRule{{Type: "BoardRole", Action: ElasticsearchDeny}}},
+		},
+		"Unknown default action": {
+			content:     `{"default": "maybe"}`,
+			expectedErr: `unknown default action "maybe"`,
+		},
+		"Unknown rule action": {
+			content:     `{"rules": [{"type": "BoardRole", "action": "skip"}]}`,
+			expectedErr: `unknown action "skip" for type BoardRole`,
+		},
+		"Rule without type": {
+			content:     `{"rules": [{"action": "deny"}]}`,
+			expectedErr: "rule 0 has no type",
+		},
+		"Unknown field": {
+			content:     `{"rules": [{"type": "BoardRole", "action": "deny", "authority": "FACTSET"}]}`,
+			expectedErr: "decoding Elasticsearch policy",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "elasticsearch.json")
+			writeElasticsearchPolicy(t, path, tc.content)
+
+			policy, err := LoadElasticsearchPolicy(path)
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPolicy, policy)
+		})
+	}
+}
+
+func TestLoadElasticsearchPolicy_NoPath(t *testing.T) {
+	policy, err := LoadElasticsearchPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultElasticsearchPolicy(), policy)
+}
+
+func TestElasticsearchPolicySource_ReloadKeepsPreviousPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elasticsearch.json")
+	writeElasticsearchPolicy(t, path, `{"default": "deny"}`)
+
+	source, err := NewElasticsearchPolicySource(path)
+	require.NoError(t, err)
+	assert.Equal(t, ElasticsearchDeny, source.Policy().Default)
+
+	writeElasticsearchPolicy(t, path, `{"default": "maybe"}`)
+	assert.Error(t, source.Reload())
+	assert.Equal(t, ElasticsearchDeny, source.Policy().Default)
+	status := source.Status()
+	assert.Equal(t, path, status.Path)
+	assert.Contains(t, status.Error, "unknown default action")
+
+	writeElasticsearchPolicy(t, path, `{"default": "allow"}`)
+	require.NoError(t, source.Reload())
+	assert.Equal(t, ElasticsearchAllow, source.Policy().Default)
+	assert.Empty(t, source.Status().Error)
+}
+
+func TestNewElasticsearchPolicySource_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elasticsearch.json")
+	writeElasticsearchPolicy(t, path, `{"rules": [{"action": "deny"}]}`)
+
+	_, err := NewElasticsearchPolicySource(path)
+	assert.Error(t, err)
+}
+
+func TestElasticsearchPolicySource_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elasticsearch.json")
+	writeElasticsearchPolicy(t, path, `{"default": "allow"}`)
+
+	source, err := NewElasticsearchPolicySource(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go source.Watch(ctx, 10*time.Millisecond)
+
+	writeElasticsearchPolicy(t, path, `{"default": "deny"}`)
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	assert.Eventually(t, func() bool {
+		return source.Policy().Default == ElasticsearchDeny
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAggregateService_ElasticsearchPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elasticsearch.json")
+	writeElasticsearchPolicy(t, path, `{"rules": [{"type": "Person", "action": "deny"}]}`)
+	source, err := NewElasticsearchPolicySource(path)
+	require.NoError(t, err)
+
+	svc := &AggregateService{}
+	assert.True(t, svc.allowedInElasticsearch(ontology.CanonicalConcept{Type: "Person"}))
+	assert.Equal(t, DefaultElasticsearchPolicy(), svc.ElasticsearchPolicy().Policy)
+
+	svc.elasticsearchPolicy = source
+	assert.False(t, svc.allowedInElasticsearch(ontology.CanonicalConcept{Type: "Person"}))
+	assert.True(t, svc.allowedInElasticsearch(ontology.CanonicalConcept{Type: "FinancialInstrument"}))
+
+	writeElasticsearchPolicy(t, path, `{"rules": []}`)
+	status, err := svc.ReloadElasticsearchPolicy()
+	require.NoError(t, err)
+	assert.Empty(t, status.Policy.Rules)
+	assert.True(t, svc.allowedInElasticsearch(ontology.CanonicalConcept{Type: "Person"}))
+}
+
+func TestElasticsearchPolicyHandler(t *testing.T) {
+	policy := ElasticsearchPolicyStatus{
+		Path:     "/etc/aggregate-concept-transformer/elasticsearch.json",
+		Policy:   ElasticsearchPolicy{Default: ElasticsearchAllow, Rules: []ElasticsearchRule{{Type: "BoardRole", Action: ElasticsearchDeny}}},
+		LoadedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	testCases := map[string]struct {
+		method         string
+		reloadErr      error
+		expectedStatus int
+	}{
+		"Report":        {method: http.MethodGet, expectedStatus: http.StatusOK},
+		"Reload":        {method: http.MethodPost, expectedStatus: http.StatusOK},
+		"Failed reload": {method: http.MethodPost, reloadErr: assert.AnError, expectedStatus: http.StatusUnprocessableEntity},
+		"Wrong method":  {method: http.MethodDelete, expectedStatus: http.StatusMethodNotAllowed},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mockService := NewMockService(nil, nil, nil, nil)
+			mockService.policy = policy
+			mockService.policyErr = tc.reloadErr
+			handler := NewHandler(mockService, time.Second)
+			sm := handler.RegisterHandlers(NewHealthService(mockService, "system-code", "app-name", 8080, "description"), false, make(chan bool))
+
+			rr := httptest.NewRecorder()
+			sm.ServeHTTP(rr, httptest.NewRequest(tc.method, "/__elasticsearch-policy", nil))
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var actual ElasticsearchPolicyStatus
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&actual))
+			assert.Equal(t, policy, actual)
+		})
+	}
+}
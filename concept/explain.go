@@ -0,0 +1,178 @@
+package concept
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/cm-graph-ontology/v2/aggregate"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+// SourceRef identifies a source concept.
+type SourceRef struct {
+	UUID      string `json:"uuid"`
+	Authority string `json:"authority"`
+}
+
+// How the sources of a field were matched. The aggregation does not record where the fields it merges come from,
+// so they are worked out afterwards by looking for the value of the field in the sources.
+const (
+	// MatchValue is a value found as it is in the sources.
+	MatchValue = "value"
+	// MatchNormalised is a string only found in the sources with a different case or spacing, as normalised by the aggregation.
+	MatchNormalised = "normalised"
+	// MatchNone is a value not found in any source, either made up by the aggregation or changed beyond recognition.
+	MatchNone = "none"
+)
+
+// FieldProvenance lists the source concepts a field of the canonical concept, or an element of one of its lists, came from.
+// Field is a JSON pointer to it. The sources are in the order they were merged in, so for a single value the last one
+// is the source it was kept from. Match tells how the sources were found, and there are none when it is MatchNone.
+type FieldProvenance struct {
+	Field   string      `json:"field"`
+	Value   interface{} `json:"value"`
+	Match   string      `json:"match"`
+	Sources []SourceRef `json:"sources"`
+}
+
+// PrimaryChoice is the source concept used as primary and the reason it was picked.
+type PrimaryChoice struct {
+	UUID      string `json:"uuid"`
+	Authority string `json:"authority"`
+	Reason    string `json:"reason"`
+}
+
+// Explanation is a canonical concept along with where each of its fields came from.
+type Explanation struct {
+	Concept       ontology.CanonicalConcept        `json:"concept"`
+	TransactionID string                           `json:"transactionID"`
+	Concordances  []concordances.ConcordanceRecord `json:"concordances"`
+	Primary       PrimaryChoice                    `json:"primary"`
+	Fields        []FieldProvenance                `json:"fields"`
+}
+
+// ExplainConcept aggregates a concept and tells which source concept each of its fields and list elements came from.
+func (s *AggregateService) ExplainConcept(ctx context.Context, UUID string) (Explanation, error) {
+	agg, err := s.gatherSources(ctx, UUID, "", time.Time{})
+	if err != nil {
+		return Explanation{}, err
+	}
+	if agg == nil {
+		return Explanation{}, fmt.Errorf("no sources found for concept %s", UUID)
+	}
+
+	concept := aggregate.CreateCanonicalConcept(agg.primary, agg.sources)
+	fields, err := fieldProvenance(concept, append(append([]ontology.SourceConcept{}, agg.sources...), agg.primary))
+	if err != nil {
+		return Explanation{}, err
+	}
+	return Explanation{
+		Concept:       concept,
+		TransactionID: agg.transactionID,
+		Concordances:  agg.records,
+		Primary:       PrimaryChoice{UUID: agg.primary.UUID, Authority: agg.primary.Authority, Reason: agg.primaryReason},
+		Fields:        fields,
+	}, nil
+}
+
+// fieldProvenance matches the fields of the canonical concept with the fields of the same name in the sources, given in merge order.
+// The elements of lists are matched one by one, as lists are merged from all the sources.
+// The source representations are left out, as they are the sources themselves.
+func fieldProvenance(concept ontology.CanonicalConcept, sources []ontology.SourceConcept) ([]FieldProvenance, error) {
+	canonicalDoc, err := toJSONDocument(concept)
+	if err != nil {
+		return nil, err
+	}
+	sourceDocs := make([]map[string]interface{}, len(sources))
+	for i, source := range sources {
+		b, err := json.Marshal(source)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &sourceDocs[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(canonicalDoc))
+	for key := range canonicalDoc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := []FieldProvenance{}
+	for _, key := range keys {
+		value := canonicalDoc[key]
+		switch {
+		case key == "sourceRepresentations":
+			continue
+		case key == "prefUUID":
+			// the UUID of the canonical concept is the UUID of its primary source
+			fields = append(fields, matchSources("/prefUUID", value, sources, sourceDocs, func(doc map[string]interface{}) []interface{} {
+				return []interface{}{doc["uuid"]}
+			}))
+		default:
+			elements, isList := value.([]interface{})
+			if !isList {
+				fields = append(fields, matchSources("/"+key, value, sources, sourceDocs, func(doc map[string]interface{}) []interface{} {
+					return []interface{}{doc[key]}
+				}))
+				continue
+			}
+			for i, element := range elements {
+				fields = append(fields, matchSources("/"+key+"/"+strconv.Itoa(i), element, sources, sourceDocs, func(doc map[string]interface{}) []interface{} {
+					sourceElements, _ := doc[key].([]interface{})
+					return sourceElements
+				}))
+			}
+		}
+	}
+	return fields, nil
+}
+
+// matchSources finds the sources with the value among the values returned by candidates for their documents,
+// falling back to the sources with the same string once normalised when none has the value as it is.
+func matchSources(field string, value interface{}, sources []ontology.SourceConcept, docs []map[string]interface{}, candidates func(doc map[string]interface{}) []interface{}) FieldProvenance {
+	find := func(equal func(candidate interface{}) bool) []SourceRef {
+		refs := []SourceRef{}
+		for i, doc := range docs {
+			for _, candidate := range candidates(doc) {
+				if equal(candidate) {
+					refs = append(refs, SourceRef{UUID: sources[i].UUID, Authority: sources[i].Authority})
+					break
+				}
+			}
+		}
+		return refs
+	}
+
+	provenance := FieldProvenance{Field: field, Value: value, Match: MatchValue}
+	if provenance.Sources = find(func(candidate interface{}) bool { return reflect.DeepEqual(candidate, value) }); len(provenance.Sources) > 0 {
+		return provenance
+	}
+	if text, ok := value.(string); ok {
+		provenance.Match = MatchNormalised
+		if provenance.Sources = find(func(candidate interface{}) bool {
+			candidateText, ok := candidate.(string)
+			return ok && normaliseText(candidateText) == normaliseText(text)
+		}); len(provenance.Sources) > 0 {
+			return provenance
+		}
+	}
+	provenance.Match = MatchNone
+	return provenance
+}
+
+// normaliseText folds the case and spacing of a string, so that values the aggregation tidied up can be matched with their sources.
+func normaliseText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -0,0 +1,117 @@
+package concept
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+func TestAggregateService_ExplainConcept(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	records := []concordances.ConcordanceRecord{
+		{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+		{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", AuthorityValue: "TME-1"},
+	}
+	svc.concordances = &mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{"28090964-9997-4bc2-9638-7a11135aaff9": records},
+	}
+	svc.nStore = &versionedS3Client{
+		versions: map[string][]conceptVersion{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				{transactionID: "tid_sl", concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Smartlogic label", Authority: "Smartlogic", Type: "Person", Aliases: []string{"Shared", "Smartlogic alias"}}},
+			},
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				{transactionID: "tid_tme", concept: ontology.SourceConcept{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", PrefLabel: "TME label", Authority: "FT-TME", Type: "Person", Aliases: []string{"TME alias", "Shared"}}},
+			},
+		},
+	}
+
+	explanation, err := svc.ExplainConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9")
+	require.NoError(t, err)
+
+	assert.Equal(t, "28090964-9997-4bc2-9638-7a11135aaff9", explanation.Concept.PrefUUID)
+	assert.Equal(t, "tid_sl", explanation.TransactionID)
+	assert.Equal(t, records, explanation.Concordances)
+	assert.Equal(t, PrimaryChoice{
+		UUID:      "28090964-9997-4bc2-9638-7a11135aaff9",
+		Authority: "Smartlogic",
+		Reason:    "Smartlogic is the most important primary authority with a single record",
+	}, explanation.Primary)
+
+	smartlogic := SourceRef{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"}
+	tme := SourceRef{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME"}
+	fields := map[string]FieldProvenance{}
+	for _, field := range explanation.Fields {
+		fields[field.Field] = field
+	}
+	assert.Equal(t, FieldProvenance{Field: "/prefUUID", Value: "28090964-9997-4bc2-9638-7a11135aaff9", Match: MatchValue, Sources: []SourceRef{smartlogic}}, fields["/prefUUID"])
+	assert.Equal(t, FieldProvenance{Field: "/prefLabel", Value: "Smartlogic label", Match: MatchValue, Sources: []SourceRef{smartlogic}}, fields["/prefLabel"])
+	assert.Equal(t, []SourceRef{tme, smartlogic}, fields["/type"].Sources, "a value found in several sources should list them in merge order")
+	for _, field := range explanation.Fields {
+		if field.Value == "Shared" {
+			assert.Equal(t, []SourceRef{tme, smartlogic}, field.Sources)
+		}
+		if field.Value == "TME alias" {
+			assert.Equal(t, []SourceRef{tme}, field.Sources)
+		}
+		assert.NotContains(t, field.Field, "/sourceRepresentations")
+	}
+}
+
+func TestFieldProvenance_NormalisedValues(t *testing.T) {
+	sources := []ontology.SourceConcept{
+		{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", Type: "Person", PrefLabel: "TME label", Aliases: []string{"  the  TME alias", "Shared"}},
+		{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic", Type: "Person", PrefLabel: "Smartlogic label", Aliases: []string{"shared"}},
+	}
+	concept := ontology.CanonicalConcept{
+		PrefUUID:  "28090964-9997-4bc2-9638-7a11135aaff9",
+		PrefLabel: "Smartlogic label",
+		Type:      "PublicCompany",
+		Aliases:   []string{"The TME alias", "Shared"},
+	}
+
+	fields, err := fieldProvenance(concept, sources)
+	require.NoError(t, err)
+	byField := map[string]FieldProvenance{}
+	for _, field := range fields {
+		byField[field.Field] = field
+	}
+
+	tme := SourceRef{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME"}
+	assert.Equal(t, FieldProvenance{Field: "/aliases/0", Value: "The TME alias", Match: MatchNormalised, Sources: []SourceRef{tme}}, byField["/aliases/0"],
+		"a value tidied up by the aggregation should be matched with its source once normalised")
+	assert.Equal(t, FieldProvenance{Field: "/aliases/1", Value: "Shared", Match: MatchValue, Sources: []SourceRef{tme}}, byField["/aliases/1"],
+		"values found as they are should not be matched with normalised ones")
+	assert.Equal(t, FieldProvenance{Field: "/type", Value: "PublicCompany", Match: MatchNone, Sources: []SourceRef{}}, byField["/type"],
+		"a value found in no source should say so")
+}
+
+func TestAggregateService_ExplainConcept_NoPrimaryAuthority(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.concordances = &mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", AuthorityValue: "TME-1"},
+			},
+		},
+	}
+	svc.nStore = &versionedS3Client{
+		versions: map[string][]conceptVersion{
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				{transactionID: "tid_tme", concept: ontology.SourceConcept{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", PrefLabel: "TME label", Authority: "FT-TME", Type: "Person"}},
+			},
+		},
+	}
+
+	explanation, err := svc.ExplainConcept(context.Background(), "34a571fb-d779-4610-a7ba-2e127676db4d")
+	require.NoError(t, err)
+	assert.Equal(t, "34a571fb-d779-4610-a7ba-2e127676db4d", explanation.Primary.UUID)
+	assert.Equal(t, "FT-TME", explanation.Primary.Authority)
+	assert.Equal(t, "there is no record of a primary authority to use, so the source with the highest authority precedence is used", explanation.Primary.Reason)
+}
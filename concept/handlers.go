@@ -3,6 +3,7 @@ package concept
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/rcrowley/go-metrics"
+	"github.com/rcrowley/go-metrics/exp"
 	log "github.com/sirupsen/logrus"
 
 	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
@@ -22,7 +24,15 @@ import (
 
 type aggregateService interface {
 	ProcessMessage(ctx context.Context, UUID string, bookmark string) error
+	ForceProcessMessage(ctx context.Context, UUID string) error
 	GetConcordedConcept(ctx context.Context, UUID string, bookmark string) (ontology.CanonicalConcept, string, error)
+	GetConcordedConceptAsOf(ctx context.Context, UUID string, asOf time.Time) (ontology.CanonicalConcept, string, error)
+	PlanConcept(ctx context.Context, UUID string, force bool) (WritePlan, error)
+	ExplainConcept(ctx context.Context, UUID string) (Explanation, error)
+	GetConceptSources(ctx context.Context, UUID string, bookmark string) (ConceptSources, error)
+	Conflicts() []ConcordanceConflict
+	ElasticsearchPolicy() ElasticsearchPolicyStatus
+	ReloadElasticsearchPolicy() (ElasticsearchPolicyStatus, error)
 }
 
 type AggregateConceptHandler struct {
@@ -49,11 +59,37 @@ func (h *AggregateConceptHandler) GetHandler(w http.ResponseWriter, r *http.Requ
 		UUID = strings.Join([]string{publication, UUID}, "-")
 	}
 
-	concept, transactionID, err := h.getConcordedConcept(ctx, UUID)
+	var asOf time.Time
+	if param := r.URL.Query().Get("asOf"); param != "" {
+		var err error
+		asOf, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			//nolint:errcheck
+			json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("asOf must be an RFC 3339 timestamp, got %q", param)})
+			return
+		}
+	}
+
+	concept, transactionID, err := h.getConcordedConcept(ctx, UUID, asOf)
 
+	var invalid *InvalidSourcesError
+	if errors.As(err, &invalid) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": err.Error(), "sources": invalid.Sources})
+		return
+	}
+	if errors.Is(err, ErrVersionsNotSupported) {
+		w.WriteHeader(http.StatusNotImplemented)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "{\"message\":\"%v\"}", err)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
 		return
 	}
 
@@ -63,7 +99,7 @@ func (h *AggregateConceptHandler) GetHandler(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(concept)
 }
 
-func (h *AggregateConceptHandler) getConcordedConcept(ctx context.Context, UUID string) (ontology.CanonicalConcept, string, error) {
+func (h *AggregateConceptHandler) getConcordedConcept(ctx context.Context, UUID string, asOf time.Time) (ontology.CanonicalConcept, string, error) {
 	type concordedTransaction struct {
 		Concept       ontology.CanonicalConcept
 		TransactionID string
@@ -74,7 +110,14 @@ func (h *AggregateConceptHandler) getConcordedConcept(ctx context.Context, UUID
 	var data concordedTransaction
 
 	go func() {
-		concordedConcept, transactionID, err := h.svc.GetConcordedConcept(ctx, UUID, "")
+		var concordedConcept ontology.CanonicalConcept
+		var transactionID string
+		var err error
+		if asOf.IsZero() {
+			concordedConcept, transactionID, err = h.svc.GetConcordedConcept(ctx, UUID, "")
+		} else {
+			concordedConcept, transactionID, err = h.svc.GetConcordedConceptAsOf(ctx, UUID, asOf)
+		}
 		transaction <- concordedTransaction{Concept: concordedConcept, TransactionID: transactionID, Err: err}
 	}()
 
@@ -95,9 +138,20 @@ func (h *AggregateConceptHandler) SendHandler(w http.ResponseWriter, r *http.Req
 	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
 	defer cancel()
 
+	force := r.URL.Query().Get("force") == "true"
+	if dryRun := r.URL.Query().Get("dryRun"); dryRun == "true" {
+		h.planConcept(ctx, w, UUID, force)
+		return
+	}
+
 	ch := make(chan error)
 	go func() {
-		err := h.svc.ProcessMessage(ctx, UUID, "")
+		var err error
+		if force {
+			err = h.svc.ForceProcessMessage(ctx, UUID)
+		} else {
+			err = h.svc.ProcessMessage(ctx, UUID, "")
+		}
 		ch <- err
 	}()
 	var err error
@@ -107,6 +161,20 @@ func (h *AggregateConceptHandler) SendHandler(w http.ResponseWriter, r *http.Req
 		err = ctx.Err()
 	}
 
+	var violations *CanonicalViolationsError
+	if errors.As(err, &violations) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": err.Error(), "violations": violations.Violations})
+		return
+	}
+	var invalid *InvalidSourcesError
+	if errors.As(err, &invalid) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": err.Error(), "sources": invalid.Sources})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprintf(w, "{\"message\":\"%v\"}", err)
@@ -116,6 +184,165 @@ func (h *AggregateConceptHandler) SendHandler(w http.ResponseWriter, r *http.Req
 	w.Write([]byte(fmt.Sprintf("{\"message\":\"Concept %s updated successfully.\"}", UUID)))
 }
 
+// planConcept responds with what sending the concept would do, without writing anything.
+func (h *AggregateConceptHandler) planConcept(ctx context.Context, w http.ResponseWriter, UUID string, force bool) {
+	type planResult struct {
+		plan WritePlan
+		err  error
+	}
+	ch := make(chan planResult, 1)
+	go func() {
+		plan, err := h.svc.PlanConcept(ctx, UUID, force)
+		ch <- planResult{plan: plan, err: err}
+	}()
+	var result planResult
+	select {
+	case result = <-ch:
+	case <-ctx.Done():
+		result.err = ctx.Err()
+	}
+
+	var invalid *InvalidSourcesError
+	if errors.As(result.err, &invalid) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": result.err.Error(), "sources": invalid.Sources})
+		return
+	}
+	if result.err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]string{"message": result.err.Error()})
+		return
+	}
+	w.Header().Set("X-Request-Id", result.plan.TransactionID)
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(result.plan)
+}
+
+// ExplainHandler responds with the canonical concept along with the source each of its fields came from.
+func (h *AggregateConceptHandler) ExplainHandler(w http.ResponseWriter, r *http.Request) {
+	UUID := mux.Vars(r)["uuid"]
+	if publication := r.URL.Query().Get("publication"); publication != "" {
+		UUID = strings.Join([]string{publication, UUID}, "-")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	type explainResult struct {
+		explanation Explanation
+		err         error
+	}
+	ch := make(chan explainResult, 1)
+	go func() {
+		explanation, err := h.svc.ExplainConcept(ctx, UUID)
+		ch <- explainResult{explanation: explanation, err: err}
+	}()
+	var result explainResult
+	select {
+	case result = <-ch:
+	case <-ctx.Done():
+		result.err = ctx.Err()
+	}
+
+	var invalid *InvalidSourcesError
+	if errors.As(result.err, &invalid) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": result.err.Error(), "sources": invalid.Sources})
+		return
+	}
+	if result.err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]string{"message": result.err.Error()})
+		return
+	}
+	w.Header().Set("X-Request-Id", result.explanation.TransactionID)
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(result.explanation)
+}
+
+// SourcesHandler responds with the concordance records of the concept and the raw source concept stored for each of them.
+func (h *AggregateConceptHandler) SourcesHandler(w http.ResponseWriter, r *http.Request) {
+	UUID := mux.Vars(r)["uuid"]
+	if publication := r.URL.Query().Get("publication"); publication != "" {
+		UUID = strings.Join([]string{publication, UUID}, "-")
+	}
+	bookmark := r.URL.Query().Get("bookmark")
+	w.Header().Set("Content-Type", "application/json")
+	ctx, cancel := context.WithTimeout(r.Context(), h.requestTimeout)
+	defer cancel()
+
+	type sourcesResult struct {
+		sources ConceptSources
+		err     error
+	}
+	ch := make(chan sourcesResult, 1)
+	go func() {
+		sources, err := h.svc.GetConceptSources(ctx, UUID, bookmark)
+		ch <- sourcesResult{sources: sources, err: err}
+	}()
+	var result sourcesResult
+	select {
+	case result = <-ch:
+	case <-ctx.Done():
+		result.err = ctx.Err()
+	}
+
+	if result.err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]string{"message": result.err.Error()})
+		return
+	}
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(result.sources)
+}
+
+// ConflictsHandler lists the concordance conflicts which have stopped concepts from being aggregated, as JSON or as CSV with format=csv.
+func (h *AggregateConceptHandler) ConflictsHandler(w http.ResponseWriter, r *http.Request) {
+	conflicts := h.svc.Conflicts()
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(conflicts)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		if err := writeConflictsCSV(w, conflicts); err != nil {
+			logger.WithError(err).Error("Error writing concordance conflicts")
+		}
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf("format must be json or csv, got %q", format)})
+	}
+}
+
+// ElasticsearchPolicyHandler reports the policy deciding which concepts are sent to Elasticsearch.
+func (h *AggregateConceptHandler) ElasticsearchPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(h.svc.ElasticsearchPolicy())
+}
+
+// ReloadElasticsearchPolicyHandler reloads the Elasticsearch policy from its file. An invalid policy is rejected and the previous one is kept.
+func (h *AggregateConceptHandler) ReloadElasticsearchPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	status, err := h.svc.ReloadElasticsearchPolicy()
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		//nolint:errcheck
+		json.NewEncoder(w).Encode(map[string]interface{}{"message": err.Error(), "status": status})
+		return
+	}
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(status)
+}
+
 func (h *AggregateConceptHandler) RegisterHandlers(healthService *HealthService, requestLoggingEnabled bool, fb chan bool) *http.ServeMux {
 	logger.Info("Registering handlers")
 
@@ -128,6 +355,8 @@ func (h *AggregateConceptHandler) RegisterHandlers(healthService *HealthService,
 	}
 	router.Handle("/concept/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}", mh)
 	router.Handle("/concept/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/send", sh)
+	router.Handle("/concept/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/explain", handlers.MethodHandler{"GET": http.HandlerFunc(h.ExplainHandler)})
+	router.Handle("/concept/{uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}}/sources", handlers.MethodHandler{"GET": http.HandlerFunc(h.SourcesHandler)})
 
 	var monitoringRouter http.Handler = router
 	if requestLoggingEnabled {
@@ -150,6 +379,12 @@ func (h *AggregateConceptHandler) RegisterHandlers(healthService *HealthService,
 	serveMux.HandleFunc("/__health", fthealth.Handler(fthealth.NewFeedbackHealthCheck(thc, fb)))
 	serveMux.HandleFunc(status.GTGPath, status.NewGoodToGoHandler(healthService.GTG))
 	serveMux.HandleFunc(status.BuildInfoPath, status.BuildInfoHandler)
+	serveMux.Handle("/__metrics", exp.ExpHandler(metrics.DefaultRegistry))
+	serveMux.Handle("/__conflicts", handlers.MethodHandler{"GET": http.HandlerFunc(h.ConflictsHandler)})
+	serveMux.Handle("/__elasticsearch-policy", handlers.MethodHandler{
+		"GET":  http.HandlerFunc(h.ElasticsearchPolicyHandler),
+		"POST": http.HandlerFunc(h.ReloadElasticsearchPolicyHandler),
+	})
 	serveMux.Handle("/", monitoringRouter)
 
 	return serveMux
@@ -18,6 +18,8 @@ import (
 	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
 	"github.com/Financial-Times/cm-graph-ontology/v2/transform"
 
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 	"github.com/Financial-Times/aggregate-concept-transformer/sqs"
 )
 
@@ -87,6 +89,71 @@ func TestHandlers(t *testing.T) {
 			},
 			err: errors.New("Canonical concept not found in S3"),
 		},
+		"Get Concept - Error with quotes": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+			resultCode: 500,
+			resultJSONBody: map[string]interface{}{
+				"message": `decoding concept: invalid character '"' after object key`,
+			},
+			err: errors.New(`decoding concept: invalid character '"' after object key`),
+		},
+		"Get Concept As Of - Success": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097?asOf=2024-03-01T12:00:00Z",
+			resultCode: 200,
+			resultJSONBody: map[string]interface{}{
+				"prefUUID":  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+				"type":      "TestConcept",
+				"prefLabel": "TestConcept",
+			},
+			concepts: map[string]transform.OldAggregatedConcept{
+				"f7fd05ea-9999-47c0-9be9-c99dd84d0097": {
+					PrefUUID:  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					Type:      "TestConcept",
+					PrefLabel: "TestConcept",
+				},
+			},
+		},
+		"Get Concept As Of - Invalid timestamp": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097?asOf=yesterday",
+			resultCode: 400,
+			resultJSONBody: map[string]interface{}{
+				"message": "asOf must be an RFC 3339 timestamp, got \"yesterday\"",
+			},
+		},
+		"Get Concept As Of - Not supported": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097?asOf=2024-03-01T12:00:00Z",
+			resultCode: 501,
+			resultJSONBody: map[string]interface{}{
+				"message": ErrVersionsNotSupported.Error(),
+			},
+			err: ErrVersionsNotSupported,
+		},
+		"Get Concept - Invalid sources": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+			resultCode: 422,
+			resultJSONBody: map[string]interface{}{
+				"message": "invalid source concepts: 34a571fb-d779-4610-a7ba-2e127676db4d (/prefLabel: length must be >= 1, but got 0)",
+				"sources": []interface{}{
+					map[string]interface{}{
+						"uuid": "34a571fb-d779-4610-a7ba-2e127676db4d",
+						"type": "Person",
+						"errors": []interface{}{
+							map[string]interface{}{"field": "/prefLabel", "error": "length must be >= 1, but got 0"},
+						},
+					},
+				},
+			},
+			err: &InvalidSourcesError{Sources: []InvalidSource{{
+				UUID:   "34a571fb-d779-4610-a7ba-2e127676db4d",
+				Type:   "Person",
+				Errors: []FieldError{{Field: "/prefLabel", Error: "length must be >= 1, but got 0"}},
+			}}},
+		},
 		"Send Concept - Success": {
 			method:     "POST",
 			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/send",
@@ -102,6 +169,141 @@ func TestHandlers(t *testing.T) {
 				},
 			},
 		},
+		"Send Concept - Forced": {
+			method:     "POST",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/send?force=true",
+			resultCode: 200,
+			resultJSONBody: map[string]interface{}{
+				"message": "Concept f7fd05ea-9999-47c0-9be9-c99dd84d0097 updated successfully.",
+			},
+			concepts: map[string]transform.OldAggregatedConcept{
+				"f7fd05ea-9999-47c0-9be9-c99dd84d0097": {
+					PrefUUID:  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					Type:      "TestConcept",
+					PrefLabel: "TestConcept",
+				},
+			},
+		},
+		"Send Concept - Dry run": {
+			method:     "POST",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/send?dryRun=true",
+			resultCode: 200,
+			resultJSONBody: map[string]interface{}{
+				"uuid":          "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+				"transactionID": "tid",
+				"type":          "TestConcept",
+				"stored":        false,
+				"diff":          []interface{}{map[string]interface{}{"field": "/prefLabel", "new": "TestConcept"}},
+				"skipped":       "content unchanged",
+				"writers":       nil,
+				"estimated":     map[string]interface{}{"purges": nil, "events": nil, "kinesisRecords": nil},
+			},
+			concepts: map[string]transform.OldAggregatedConcept{
+				"f7fd05ea-9999-47c0-9be9-c99dd84d0097": {
+					PrefUUID:  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					Type:      "TestConcept",
+					PrefLabel: "TestConcept",
+				},
+			},
+		},
+		"Send Concept - Forced dry run": {
+			method:     "POST",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/send?dryRun=true&force=true",
+			resultCode: 200,
+			resultJSONBody: map[string]interface{}{
+				"uuid":          "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+				"transactionID": "tid",
+				"type":          "TestConcept",
+				"stored":        false,
+				"diff":          []interface{}{map[string]interface{}{"field": "/prefLabel", "new": "TestConcept"}},
+				"writers":       nil,
+				"estimated":     map[string]interface{}{"purges": nil, "events": nil, "kinesisRecords": nil},
+			},
+			concepts: map[string]transform.OldAggregatedConcept{
+				"f7fd05ea-9999-47c0-9be9-c99dd84d0097": {
+					PrefUUID:  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					Type:      "TestConcept",
+					PrefLabel: "TestConcept",
+				},
+			},
+		},
+		"Explain Concept - Success": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/explain",
+			resultCode: 200,
+			resultJSONBody: map[string]interface{}{
+				"concept": map[string]interface{}{
+					"prefUUID":  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					"type":      "TestConcept",
+					"prefLabel": "TestConcept",
+				},
+				"transactionID": "tid",
+				"concordances":  nil,
+				"primary": map[string]interface{}{
+					"uuid":      "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					"authority": "Smartlogic",
+					"reason":    "Smartlogic is the most important primary authority with a single record",
+				},
+				"fields": []interface{}{map[string]interface{}{
+					"field":   "/prefLabel",
+					"value":   "TestConcept",
+					"match":   "value",
+					"sources": []interface{}{map[string]interface{}{"uuid": "f7fd05ea-9999-47c0-9be9-c99dd84d0097", "authority": "Smartlogic"}},
+				}},
+			},
+			concepts: map[string]transform.OldAggregatedConcept{
+				"f7fd05ea-9999-47c0-9be9-c99dd84d0097": {
+					PrefUUID:  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					Type:      "TestConcept",
+					PrefLabel: "TestConcept",
+				},
+			},
+		},
+		"Explain Concept - Failure": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/explain",
+			resultCode: 500,
+			resultJSONBody: map[string]interface{}{
+				"message": "could not aggregate the concept",
+			},
+			err: errors.New("could not aggregate the concept"),
+		},
+		"Get Concept Sources - Success": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/sources",
+			resultCode: 200,
+			resultJSONBody: map[string]interface{}{
+				"uuid": "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+				"sources": []interface{}{map[string]interface{}{
+					"record":        map[string]interface{}{"uuid": "f7fd05ea-9999-47c0-9be9-c99dd84d0097", "authority": "Smartlogic", "authorityValue": ""},
+					"found":         true,
+					"key":           "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					"transactionID": "tid",
+					"concept": map[string]interface{}{
+						"uuid":      "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+						"prefLabel": "TestConcept",
+						"type":      "TestConcept",
+						"authority": "Smartlogic",
+					},
+				}},
+			},
+			concepts: map[string]transform.OldAggregatedConcept{
+				"f7fd05ea-9999-47c0-9be9-c99dd84d0097": {
+					PrefUUID:  "f7fd05ea-9999-47c0-9be9-c99dd84d0097",
+					Type:      "TestConcept",
+					PrefLabel: "TestConcept",
+				},
+			},
+		},
+		"Get Concept Sources - Failure": {
+			method:     "GET",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/sources?bookmark=FB:kcwQnrEEnFpfSJ2PtiykK",
+			resultCode: 500,
+			resultJSONBody: map[string]interface{}{
+				"message": "could not read the concordances",
+			},
+			err: errors.New("could not read the concordances"),
+		},
 		"Send Concept - Failure": {
 			method:     "POST",
 			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/send",
@@ -111,6 +313,38 @@ func TestHandlers(t *testing.T) {
 			},
 			err: errors.New("could not process the concept"),
 		},
+		"Send Concept - Invalid canonical concept": {
+			method:     "POST",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/send",
+			resultCode: 422,
+			resultJSONBody: map[string]interface{}{
+				"message":    "canonical concept f7fd05ea-9999-47c0-9be9-c99dd84d0097 of type Membership is invalid: Membership has no HAS_MEMBER relationship",
+				"violations": []interface{}{"Membership has no HAS_MEMBER relationship"},
+			},
+			err: &CanonicalViolationsError{UUID: "f7fd05ea-9999-47c0-9be9-c99dd84d0097", Type: "Membership", Violations: []string{"Membership has no HAS_MEMBER relationship"}},
+		},
+		"Send Concept - Invalid sources": {
+			method:     "POST",
+			url:        "/concept/f7fd05ea-9999-47c0-9be9-c99dd84d0097/send",
+			resultCode: 422,
+			resultJSONBody: map[string]interface{}{
+				"message": "invalid source concepts: 34a571fb-d779-4610-a7ba-2e127676db4d (/prefLabel: length must be >= 1, but got 0)",
+				"sources": []interface{}{
+					map[string]interface{}{
+						"uuid": "34a571fb-d779-4610-a7ba-2e127676db4d",
+						"type": "Person",
+						"errors": []interface{}{
+							map[string]interface{}{"field": "/prefLabel", "error": "length must be >= 1, but got 0"},
+						},
+					},
+				},
+			},
+			err: failure.Wrap(failure.Validation, &InvalidSourcesError{Sources: []InvalidSource{{
+				UUID:   "34a571fb-d779-4610-a7ba-2e127676db4d",
+				Type:   "Person",
+				Errors: []FieldError{{Field: "/prefLabel", Error: "length must be >= 1, but got 0"}},
+			}}}),
+		},
 		"GTG - Success": {
 			method:         "GET",
 			url:            "/__gtg",
@@ -193,6 +427,9 @@ type MockService struct {
 	concepts      map[string]transform.OldAggregatedConcept
 	m             sync.RWMutex
 	healthchecks  []fthealth.Check
+	conflicts     []ConcordanceConflict
+	policy        ElasticsearchPolicyStatus
+	policyErr     error
 	err           error
 }
 
@@ -212,6 +449,10 @@ func (s *MockService) ProcessMessage(ctx context.Context, UUID string, bookmark
 	return nil
 }
 
+func (s *MockService) ForceProcessMessage(ctx context.Context, UUID string) error {
+	return s.ProcessMessage(ctx, UUID, "")
+}
+
 func (s *MockService) GetConcordedConcept(ctx context.Context, UUID string, bookmark string) (ontology.CanonicalConcept, string, error) {
 	if s.err != nil {
 		return ontology.CanonicalConcept{}, "", s.err
@@ -227,6 +468,59 @@ func (s *MockService) GetConcordedConcept(ctx context.Context, UUID string, book
 	return newConcept, "tid", nil
 }
 
+func (s *MockService) GetConcordedConceptAsOf(ctx context.Context, UUID string, asOf time.Time) (ontology.CanonicalConcept, string, error) {
+	return s.GetConcordedConcept(ctx, UUID, "")
+}
+
+func (s *MockService) PlanConcept(ctx context.Context, UUID string, force bool) (WritePlan, error) {
+	c, tid, err := s.GetConcordedConcept(ctx, UUID, "")
+	if err != nil {
+		return WritePlan{}, err
+	}
+	plan := WritePlan{UUID: c.PrefUUID, TransactionID: tid, Type: c.Type, Diff: []FieldChange{{Field: "/prefLabel", New: c.PrefLabel}}}
+	if !force {
+		plan.Skipped = skippedUnchanged
+	}
+	return plan, nil
+}
+
+func (s *MockService) ExplainConcept(ctx context.Context, UUID string) (Explanation, error) {
+	c, tid, err := s.GetConcordedConcept(ctx, UUID, "")
+	if err != nil {
+		return Explanation{}, err
+	}
+	return Explanation{
+		Concept:       c,
+		TransactionID: tid,
+		Primary:       PrimaryChoice{UUID: c.PrefUUID, Authority: "Smartlogic", Reason: "Smartlogic is the most important primary authority with a single record"},
+		Fields:        []FieldProvenance{{Field: "/prefLabel", Value: c.PrefLabel, Match: MatchValue, Sources: []SourceRef{{UUID: c.PrefUUID, Authority: "Smartlogic"}}}},
+	}, nil
+}
+
+func (s *MockService) GetConceptSources(ctx context.Context, UUID string, bookmark string) (ConceptSources, error) {
+	c, tid, err := s.GetConcordedConcept(ctx, UUID, bookmark)
+	if err != nil {
+		return ConceptSources{}, err
+	}
+	source := ontology.SourceConcept{UUID: c.PrefUUID, PrefLabel: c.PrefLabel, Type: c.Type, Authority: "Smartlogic"}
+	return ConceptSources{
+		UUID:    c.PrefUUID,
+		Sources: []SourceEntry{{Record: concordances.ConcordanceRecord{UUID: c.PrefUUID, Authority: "Smartlogic"}, Found: true, Key: c.PrefUUID, TransactionID: tid, Concept: &source}},
+	}, nil
+}
+
+func (s *MockService) Conflicts() []ConcordanceConflict {
+	return s.conflicts
+}
+
+func (s *MockService) ElasticsearchPolicy() ElasticsearchPolicyStatus {
+	return s.policy
+}
+
+func (s *MockService) ReloadElasticsearchPolicy() (ElasticsearchPolicyStatus, error) {
+	return s.policy, s.policyErr
+}
+
 func (s *MockService) Healthchecks() []fthealth.Check {
 	if s.healthchecks != nil {
 		return s.healthchecks
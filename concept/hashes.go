@@ -0,0 +1,133 @@
+package concept
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/lru"
+)
+
+// ContentHashStore remembers the content hash of the last canonical concept written for each PrefUUID,
+// so that an update which would not change the concept can skip the writers.
+type ContentHashStore interface {
+	Get(ctx context.Context, UUID string) (string, bool, error)
+	Set(ctx context.Context, UUID string, hash string) error
+}
+
+// writeConfig is the configuration deciding which writers a concept is sent to and what is purged along with it.
+// It is hashed along with the concept, so that every concept is written again once it changes.
+type writeConfig struct {
+	ElasticsearchPolicy             ElasticsearchPolicy `json:"elasticsearchPolicy"`
+	PurgeRules                      PurgeRules          `json:"purgeRules"`
+	TypesToPurgeFromPublicEndpoints []string            `json:"typesToPurgeFromPublicEndpoints"`
+}
+
+// writeConfig returns the configuration currently in use, including the last Elasticsearch policy loaded.
+func (s *AggregateService) writeConfig() writeConfig {
+	return writeConfig{
+		ElasticsearchPolicy:             s.ElasticsearchPolicy().Policy,
+		PurgeRules:                      s.purgeRules,
+		TypesToPurgeFromPublicEndpoints: s.typesToPurgeFromPublicEndpoints,
+	}
+}
+
+// contentHash returns the SHA-256 of the JSON of the concept and of the configuration it is written with.
+// The JSON is stable, as the fields are always encoded in the same order
+// and the sources are aggregated in the same order whatever order their concordances are returned in.
+func contentHash(concept ontology.CanonicalConcept, config writeConfig) (string, error) {
+	b, err := json.Marshal(struct {
+		Concept ontology.CanonicalConcept `json:"concept"`
+		Config  writeConfig               `json:"config"`
+	}{concept, config})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// MemoryHashStore keeps the content hashes of the most recently written concepts in memory. They are lost when the service restarts.
+type MemoryHashStore struct {
+	hashes *lru.Cache[string, string]
+}
+
+// NewMemoryHashStore keeps the content hashes of up to size concepts.
+func NewMemoryHashStore(size int) *MemoryHashStore {
+	return &MemoryHashStore{hashes: lru.New[string, string](size, 0)}
+}
+
+func (s *MemoryHashStore) Get(_ context.Context, UUID string) (string, bool, error) {
+	hash, ok := s.hashes.Get(UUID)
+	return hash, ok, nil
+}
+
+func (s *MemoryHashStore) Set(_ context.Context, UUID string, hash string) error {
+	s.hashes.Add(UUID, hash)
+	return nil
+}
+
+// FileHashStore keeps the content hashes in a directory, one file per concept, so they are kept when the service restarts.
+type FileHashStore struct {
+	dir string
+}
+
+// NewFileHashStore keeps the content hashes in dir, creating it if needed.
+func NewFileHashStore(dir string) (*FileHashStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileHashStore{dir: dir}, nil
+}
+
+func (s *FileHashStore) path(UUID string) (string, error) {
+	if !filepath.IsLocal(UUID) {
+		return "", fmt.Errorf("invalid concept UUID %q", UUID)
+	}
+	return filepath.Join(s.dir, UUID), nil
+}
+
+func (s *FileHashStore) Get(_ context.Context, UUID string) (string, bool, error) {
+	path, err := s.path(UUID)
+	if err != nil {
+		return "", false, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(b)), true, nil
+}
+
+// Set writes the hash to a temporary file which then replaces the previous one, so a hash is never read half written.
+func (s *FileHashStore) Set(_ context.Context, UUID string, hash string) error {
+	path, err := s.path(UUID)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, UUID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(hash); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
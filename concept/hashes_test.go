@@ -0,0 +1,157 @@
+package concept
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+)
+
+func TestContentHash(t *testing.T) {
+	concept := ontology.CanonicalConcept{PrefUUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Label", Type: "Person"}
+	config := writeConfig{ElasticsearchPolicy: DefaultElasticsearchPolicy(), PurgeRules: DefaultPurgeRules()}
+	hash, err := contentHash(concept, config)
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	same, err := contentHash(concept, config)
+	require.NoError(t, err)
+	assert.Equal(t, hash, same)
+
+	concept.PrefLabel = "Other label"
+	other, err := contentHash(concept, config)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestContentHash_ChangesWithConfig(t *testing.T) {
+	concept := ontology.CanonicalConcept{PrefUUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Label", Type: "Person"}
+	config := writeConfig{ElasticsearchPolicy: DefaultElasticsearchPolicy(), PurgeRules: DefaultPurgeRules(), TypesToPurgeFromPublicEndpoints: []string{"Person"}}
+	hash, err := contentHash(concept, config)
+	require.NoError(t, err)
+
+	testCases := map[string]writeConfig{
+		"Elasticsearch policy": {ElasticsearchPolicy: ElasticsearchPolicy{Default: ElasticsearchDeny}, PurgeRules: DefaultPurgeRules(), TypesToPurgeFromPublicEndpoints: []string{"Person"}},
+		"Purge rules":          {ElasticsearchPolicy: DefaultElasticsearchPolicy(), TypesToPurgeFromPublicEndpoints: []string{"Person"}},
+		"Types to purge":       {ElasticsearchPolicy: DefaultElasticsearchPolicy(), PurgeRules: DefaultPurgeRules()},
+	}
+	for name, changed := range testCases {
+		t.Run(name, func(t *testing.T) {
+			other, err := contentHash(concept, changed)
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other)
+		})
+	}
+}
+
+func TestContentHashStores(t *testing.T) {
+	fileStore, err := NewFileHashStore(filepath.Join(t.TempDir(), "hashes"))
+	require.NoError(t, err)
+	stores := map[string]ContentHashStore{
+		"Memory": NewMemoryHashStore(10),
+		"File":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			_, found, err := store.Get(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9")
+			require.NoError(t, err)
+			assert.False(t, found)
+
+			require.NoError(t, store.Set(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "hash1"))
+			require.NoError(t, store.Set(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "hash2"))
+			hash, found, err := store.Get(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9")
+			require.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, "hash2", hash)
+		})
+	}
+}
+
+func TestFileHashStore_KeptAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileHashStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "hash"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files should be removed")
+
+	restarted, err := NewFileHashStore(dir)
+	require.NoError(t, err)
+	hash, found, err := restarted.Get(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "hash", hash)
+
+	_, _, err = restarted.Get(context.Background(), "../28090964-9997-4bc2-9638-7a11135aaff9")
+	assert.Error(t, err)
+}
+
+func countCalls(called []string, prefix string) int {
+	var count int
+	for _, c := range called {
+		if strings.HasPrefix(c, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestAggregateService_ProcessMessage_SkipsUnchangedConcept(t *testing.T) {
+	svc, _, _, eventQueue, _, _, _ := setupTestService(200, payload)
+	svc.contentHashes = NewMemoryHashStore(10)
+	mockWriter := svc.httpClient.(*mockHTTPClient)
+
+	require.NoError(t, svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", ""))
+	require.NoError(t, svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", ""))
+	assert.Equal(t, 1, countCalls(mockWriter.called, neo4jUrl), "an update which does not change the concept should not be written")
+	assert.Equal(t, 1, countCalls(mockWriter.called, esUrl))
+	assert.Equal(t, 1, countCalls(mockWriter.called, varnishPurgerUrl))
+	assert.Equal(t, 3, len(eventQueue.eventList))
+
+	require.NoError(t, svc.ForceProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9"))
+	assert.Equal(t, 2, countCalls(mockWriter.called, neo4jUrl), "a forced update should be written")
+	assert.Equal(t, 2, countCalls(mockWriter.called, esUrl))
+}
+
+func TestAggregateService_ProcessMessage_WritesAgainAfterPolicyChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "elasticsearch.json")
+	writeElasticsearchPolicy(t, path, `{"default": "deny"}`)
+	source, err := NewElasticsearchPolicySource(path)
+	require.NoError(t, err)
+
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.contentHashes = NewMemoryHashStore(10)
+	svc.elasticsearchPolicy = source
+	mockWriter := svc.httpClient.(*mockHTTPClient)
+
+	require.NoError(t, svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", ""))
+	assert.Equal(t, 0, countCalls(mockWriter.called, esUrl))
+
+	writeElasticsearchPolicy(t, path, `{"default": "allow"}`)
+	_, err = svc.ReloadElasticsearchPolicy()
+	require.NoError(t, err)
+	require.NoError(t, svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", ""))
+	assert.Equal(t, 2, countCalls(mockWriter.called, neo4jUrl), "an unchanged concept should be written again once the policy changes")
+	assert.Equal(t, 1, countCalls(mockWriter.called, esUrl))
+}
+
+func TestAggregateService_ProcessMessage_FailedWriteIsNotSkipped(t *testing.T) {
+	svc, _, _, _, mockKinesisClient, _, _ := setupTestService(200, payload)
+	svc.contentHashes = NewMemoryHashStore(10)
+	mockKinesisClient.err = errors.New("failed to add record to stream")
+	mockWriter := svc.httpClient.(*mockHTTPClient)
+
+	assert.Error(t, svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", ""))
+	mockKinesisClient.err = nil
+	assert.NoError(t, svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", ""))
+	assert.Equal(t, 2, countCalls(mockWriter.called, neo4jUrl), "the retry of a failed update should be written")
+}
@@ -5,11 +5,13 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
 
 	"github.com/Financial-Times/go-logger"
 )
 
 type mockHTTPClient struct {
+	sync.Mutex
 	resp         string
 	statusCode   int
 	err          error
@@ -22,6 +24,8 @@ func init() {
 }
 
 func (c *mockHTTPClient) Do(req *http.Request) (resp *http.Response, err error) {
+	c.Lock()
+	defer c.Unlock()
 	c.called = append(c.called, req.URL.String())
 	c.capturedBody = req.Body
 	cb := ioutil.NopCloser(bytes.NewReader([]byte(c.resp)))
@@ -0,0 +1,39 @@
+package concept
+
+import (
+	"sort"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+// authorityPrecedence ranks authorities so concorded concepts are always aggregated in the same order.
+// Authorities which are not configured rank below every configured one.
+type authorityPrecedence map[string]int
+
+// newAuthorityPrecedence ranks the given authorities from the most to the least important one.
+func newAuthorityPrecedence(authorities []string) authorityPrecedence {
+	precedence := authorityPrecedence{}
+	for i, authority := range authorities {
+		if _, ok := precedence[authority]; ok {
+			continue
+		}
+		precedence[authority] = len(authorities) - i
+	}
+	return precedence
+}
+
+// sortConcordances orders records from the lowest to the highest precedence, so the most important source is aggregated last
+// and is the one used as the primary concept when there is no primary authority.
+// Records of equal precedence are ordered by authority and then by UUID.
+func (p authorityPrecedence) sortConcordances(records []concordances.ConcordanceRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if p[a.Authority] != p[b.Authority] {
+			return p[a.Authority] < p[b.Authority]
+		}
+		if a.Authority != b.Authority {
+			return a.Authority < b.Authority
+		}
+		return a.UUID < b.UUID
+	})
+}
@@ -0,0 +1,29 @@
+package concept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+func TestAuthorityPrecedence_SortConcordances(t *testing.T) {
+	precedence := newAuthorityPrecedence([]string{"Smartlogic", "ManagedLocation", "FACTSET", "TME", "FACTSET"})
+	records := []concordances.ConcordanceRecord{
+		{UUID: "tme-2", Authority: "TME"},
+		{UUID: "factset-1", Authority: "FACTSET"},
+		{UUID: "wikidata-1", Authority: "Wikidata"},
+		{UUID: "tme-1", Authority: "TME"},
+		{UUID: "ml-1", Authority: "ManagedLocation"},
+		{UUID: "dbpedia-1", Authority: "DBPedia"},
+	}
+
+	precedence.sortConcordances(records)
+
+	var uuids []string
+	for _, r := range records {
+		uuids = append(uuids, r.UUID)
+	}
+	assert.Equal(t, []string{"dbpedia-1", "wikidata-1", "tme-1", "tme-2", "factset-1", "ml-1"}, uuids)
+}
@@ -0,0 +1,185 @@
+package concept
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Financial-Times/go-logger"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+// OnMultiple tells how to handle a concept concorded to more than one record of the same primary authority.
+type OnMultiple string
+
+const (
+	// OnMultipleError fails the update, as the conflict has to be fixed in the concordance store.
+	OnMultipleError OnMultiple = "error"
+	// OnMultipleIgnore does not use the authority as primary, so its records are aggregated as ordinary sources.
+	OnMultipleIgnore OnMultiple = "ignore"
+	// OnMultipleLowestUUID uses the record with the lowest UUID as primary and aggregates the others as ordinary sources.
+	// The choice is arbitrary but stable. There is deliberately no rule picking the most recent record, as neither the concordance
+	// records nor S3 tell when a record became the right one, and republishing a source would then change the primary record.
+	OnMultipleLowestUUID OnMultiple = "lowestUUID"
+
+	// onMultipleMostRecent is not supported, and is only known so that configuring it fails with an explanation.
+	onMultipleMostRecent OnMultiple = "mostRecent"
+)
+
+// PrimaryAuthorityRule makes an authority a primary authority.
+type PrimaryAuthorityRule struct {
+	Authority  string     `json:"authority"`
+	OnMultiple OnMultiple `json:"onMultiple"`
+}
+
+// PrimaryAuthorityRules lists the primary authorities from the most to the least important one.
+// The primary concept of a concorded concept comes from the first authority which resolves to a single record.
+type PrimaryAuthorityRules []PrimaryAuthorityRule
+
+// DefaultPrimaryAuthorityRules returns the rules used when none are configured.
+func DefaultPrimaryAuthorityRules() PrimaryAuthorityRules {
+	return PrimaryAuthorityRules{
+		{Authority: ontology.SmartlogicAuthority, OnMultiple: OnMultipleError},
+		{Authority: ontology.ManagedLocationAuthority, OnMultiple: OnMultipleError},
+	}
+}
+
+// LoadPrimaryAuthorityRules reads the rules from a JSON file, falling back to the default rules when no file is given.
+func LoadPrimaryAuthorityRules(path string) (PrimaryAuthorityRules, error) {
+	if path == "" {
+		return DefaultPrimaryAuthorityRules(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var config struct {
+		PrimaryAuthorities PrimaryAuthorityRules `json:"primaryAuthorities"`
+	}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("decoding primary authority rules from %s: %w", path, err)
+	}
+	rules := config.PrimaryAuthorities
+	if err = rules.validate(); err != nil {
+		return nil, fmt.Errorf("invalid primary authority rules in %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+func (r PrimaryAuthorityRules) validate() error {
+	seen := map[string]bool{}
+	for i := range r {
+		rule := &r[i]
+		if rule.Authority == "" {
+			return fmt.Errorf("rule %d has no authority", i)
+		}
+		if seen[rule.Authority] {
+			return fmt.Errorf("authority %s has more than one rule", rule.Authority)
+		}
+		seen[rule.Authority] = true
+
+		switch rule.OnMultiple {
+		case "":
+			rule.OnMultiple = OnMultipleError
+		case OnMultipleError, OnMultipleIgnore, OnMultipleLowestUUID:
+		case onMultipleMostRecent:
+			return fmt.Errorf("onMultiple %q for authority %s is not supported, as there is no reliable time a record became the right one: use %q or %q instead",
+				rule.OnMultiple, rule.Authority, OnMultipleLowestUUID, OnMultipleError)
+		default:
+			return fmt.Errorf("unknown onMultiple %q for authority %s", rule.OnMultiple, rule.Authority)
+		}
+	}
+	return nil
+}
+
+// bucketConcordances picks the record the primary concept is read from and returns the remaining records as sources,
+// along with the reason the primary record was picked. The primary record is empty when none of the records belong to a primary authority.
+func bucketConcordances(concordanceRecords []concordances.ConcordanceRecord, rules PrimaryAuthorityRules) (concordances.ConcordanceRecord, []concordances.ConcordanceRecord, string, error) {
+	if len(concordanceRecords) == 0 {
+		err := failure.Wrap(failure.Permanent, fmt.Errorf("no concordances provided"))
+		logger.WithError(err).Error("Error grouping concordance records")
+		return concordances.ConcordanceRecord{}, nil, "", err
+	}
+
+	bucketedConcordances := map[string][]concordances.ConcordanceRecord{}
+	for _, v := range concordanceRecords {
+		bucketedConcordances[v.Authority] = append(bucketedConcordances[v.Authority], v)
+	}
+
+	var primary concordances.ConcordanceRecord
+	var conflict *PrimaryAuthorityConflictError
+	var reasons []string
+	for _, rule := range rules {
+		records := bucketedConcordances[rule.Authority]
+		switch {
+		case len(records) == 0:
+			continue
+		case len(records) == 1:
+			if primary.UUID == "" {
+				primary = records[0]
+				reasons = append(reasons, fmt.Sprintf("%s is the most important primary authority with a single record", rule.Authority))
+			}
+		case rule.OnMultiple == OnMultipleError:
+			// every conflict is reported, even one in an authority which would not have been used as primary
+			if conflict == nil {
+				conflict = &PrimaryAuthorityConflictError{}
+			}
+			conflict.Authorities = append(conflict.Authorities, rule.Authority)
+			conflict.Records = append(conflict.Records, records...)
+		case rule.OnMultiple == OnMultipleLowestUUID:
+			if primary.UUID == "" {
+				primary = records[0]
+				for _, record := range records[1:] {
+					if record.UUID < primary.UUID {
+						primary = record
+					}
+				}
+				reasons = append(reasons, fmt.Sprintf("%s has %d records, the one with the lowest UUID is used", rule.Authority, len(records)))
+			}
+		case rule.OnMultiple == OnMultipleIgnore:
+			if primary.UUID == "" {
+				reasons = append(reasons, fmt.Sprintf("%s has %d records and is ignored", rule.Authority, len(records)))
+			}
+		}
+	}
+	if conflict != nil {
+		logger.WithError(conflict).
+			WithField("alert_tag", "AggregateConceptTransformerMultiplePrimaryAuthorities").
+			WithField("conflicting_authorities", conflict.Authorities).
+			WithField("primary_authorities", conflict.Records).
+			Error("Error grouping concordance records")
+		// the conflict has to be fixed in the concordance store, retrying the update will not help
+		return concordances.ConcordanceRecord{}, nil, "", failure.Wrap(failure.Permanent, conflict)
+	}
+	if primary.UUID == "" {
+		reasons = append(reasons, "there is no record of a primary authority to use")
+	}
+
+	sources := make([]concordances.ConcordanceRecord, 0, len(concordanceRecords))
+	for _, record := range concordanceRecords {
+		if record != primary {
+			sources = append(sources, record)
+		}
+	}
+	return primary, sources, strings.Join(reasons, "; "), nil
+}
+
+// PrimaryAuthorityConflictError is returned when a concept is concorded to more than one record of primary authorities
+// which fail on multiple records. Records are the records of all the conflicting authorities.
+type PrimaryAuthorityConflictError struct {
+	Authorities []string
+	Records     []concordances.ConcordanceRecord
+}
+
+func (e *PrimaryAuthorityConflictError) Error() string {
+	return "more than 1 primary authority"
+}
@@ -0,0 +1,198 @@
+package concept
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+func TestBucketConcordances(t *testing.T) {
+	sl1 := concordances.ConcordanceRecord{UUID: "b-smartlogic", Authority: "Smartlogic"}
+	sl2 := concordances.ConcordanceRecord{UUID: "a-smartlogic", Authority: "Smartlogic"}
+	ml1 := concordances.ConcordanceRecord{UUID: "d-managedlocation", Authority: "ManagedLocation"}
+	ml2 := concordances.ConcordanceRecord{UUID: "c-managedlocation", Authority: "ManagedLocation"}
+	tme := concordances.ConcordanceRecord{UUID: "e-tme", Authority: "TME"}
+
+	testCases := map[string]struct {
+		rules            PrimaryAuthorityRules
+		records          []concordances.ConcordanceRecord
+		expectedPrimary  concordances.ConcordanceRecord
+		expectedSources  []concordances.ConcordanceRecord
+		expectedReason   string
+		expectedErr      string
+		expectedConflict []string
+	}{
+		"No concordances": {
+			rules:       DefaultPrimaryAuthorityRules(),
+			expectedErr: "no concordances provided",
+		},
+		"Smartlogic is primary": {
+			rules:           DefaultPrimaryAuthorityRules(),
+			records:         []concordances.ConcordanceRecord{tme, sl1, ml1},
+			expectedPrimary: sl1,
+			expectedSources: []concordances.ConcordanceRecord{tme, ml1},
+			expectedReason:  "Smartlogic is the most important primary authority with a single record",
+		},
+		"ManagedLocation is primary without Smartlogic": {
+			rules:           DefaultPrimaryAuthorityRules(),
+			records:         []concordances.ConcordanceRecord{tme, ml1},
+			expectedPrimary: ml1,
+			expectedSources: []concordances.ConcordanceRecord{tme},
+		},
+		"No primary authority": {
+			rules:           DefaultPrimaryAuthorityRules(),
+			records:         []concordances.ConcordanceRecord{tme},
+			expectedSources: []concordances.ConcordanceRecord{tme},
+			expectedReason:  "there is no record of a primary authority to use",
+		},
+		"No rules": {
+			records:         []concordances.ConcordanceRecord{sl1, tme},
+			expectedSources: []concordances.ConcordanceRecord{sl1, tme},
+		},
+		"Rules are applied in order": {
+			rules: PrimaryAuthorityRules{
+				{Authority: "ManagedLocation", OnMultiple: OnMultipleError},
+				{Authority: "Smartlogic", OnMultiple: OnMultipleError},
+			},
+			records:         []concordances.ConcordanceRecord{sl1, ml1},
+			expectedPrimary: ml1,
+			expectedSources: []concordances.ConcordanceRecord{sl1},
+		},
+		"Multiple Smartlogic is an error": {
+			rules:            DefaultPrimaryAuthorityRules(),
+			records:          []concordances.ConcordanceRecord{sl1, sl2, tme},
+			expectedErr:      "more than 1 primary authority",
+			expectedConflict: []string{"Smartlogic"},
+		},
+		"Multiple ManagedLocation is an error even with a Smartlogic primary": {
+			rules:            DefaultPrimaryAuthorityRules(),
+			records:          []concordances.ConcordanceRecord{sl1, ml1, ml2},
+			expectedErr:      "more than 1 primary authority",
+			expectedConflict: []string{"ManagedLocation"},
+		},
+		"Multiple ignored": {
+			rules: PrimaryAuthorityRules{
+				{Authority: "Smartlogic", OnMultiple: OnMultipleIgnore},
+				{Authority: "ManagedLocation", OnMultiple: OnMultipleError},
+			},
+			records:         []concordances.ConcordanceRecord{sl1, sl2, ml1},
+			expectedPrimary: ml1,
+			expectedSources: []concordances.ConcordanceRecord{sl1, sl2},
+			expectedReason:  "Smartlogic has 2 records and is ignored; ManagedLocation is the most important primary authority with a single record",
+		},
+		"Multiple ignored without another primary": {
+			rules: PrimaryAuthorityRules{
+				{Authority: "Smartlogic", OnMultiple: OnMultipleIgnore},
+			},
+			records:         []concordances.ConcordanceRecord{sl1, sl2, tme},
+			expectedSources: []concordances.ConcordanceRecord{sl1, sl2, tme},
+		},
+		"Multiple resolved by lowest UUID": {
+			rules: PrimaryAuthorityRules{
+				{Authority: "Smartlogic", OnMultiple: OnMultipleLowestUUID},
+				{Authority: "ManagedLocation", OnMultiple: OnMultipleError},
+			},
+			records:         []concordances.ConcordanceRecord{sl1, ml1, sl2},
+			expectedPrimary: sl2,
+			expectedSources: []concordances.ConcordanceRecord{sl1, ml1},
+			expectedReason:  "Smartlogic has 2 records, the one with the lowest UUID is used",
+		},
+		"Lowest UUID does not override a higher primary authority": {
+			rules: PrimaryAuthorityRules{
+				{Authority: "Smartlogic", OnMultiple: OnMultipleError},
+				{Authority: "ManagedLocation", OnMultiple: OnMultipleLowestUUID},
+			},
+			records:         []concordances.ConcordanceRecord{ml1, ml2, sl1},
+			expectedPrimary: sl1,
+			expectedSources: []concordances.ConcordanceRecord{ml1, ml2},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			primary, sources, reason, err := bucketConcordances(tc.records, tc.rules)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.Equal(t, failure.Permanent, failure.KindOf(err))
+				if tc.expectedConflict != nil {
+					var conflict *PrimaryAuthorityConflictError
+					require.ErrorAs(t, err, &conflict)
+					assert.Equal(t, tc.expectedConflict, conflict.Authorities)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPrimary, primary)
+			assert.ElementsMatch(t, tc.expectedSources, sources)
+			if tc.expectedReason != "" {
+				assert.Equal(t, tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestLoadPrimaryAuthorityRules(t *testing.T) {
+	testCases := map[string]struct {
+		config        string
+		expectedRules PrimaryAuthorityRules
+		expectedErr   string
+	}{
+		"Valid rules": {
+			config: `{"primaryAuthorities": [{"authority": "ManagedLocation", "onMultiple": "lowestUUID"}, {"authority": "Smartlogic"}]}`,
+			expectedRules: PrimaryAuthorityRules{
+				{Authority: "ManagedLocation", OnMultiple: OnMultipleLowestUUID},
+				{Authority: "Smartlogic", OnMultiple: OnMultipleError},
+			},
+		},
+		"Unknown onMultiple": {
+			config:      `{"primaryAuthorities": [{"authority": "Smartlogic", "onMultiple": "newest"}]}`,
+			expectedErr: `unknown onMultiple "newest" for authority Smartlogic`,
+		},
+		"Most recent is not supported": {
+			config:      `{"primaryAuthorities": [{"authority": "Smartlogic", "onMultiple": "mostRecent"}]}`,
+			expectedErr: `onMultiple "mostRecent" for authority Smartlogic is not supported`,
+		},
+		"Duplicate authority": {
+			config:      `{"primaryAuthorities": [{"authority": "Smartlogic"}, {"authority": "Smartlogic", "onMultiple": "ignore"}]}`,
+			expectedErr: "authority Smartlogic has more than one rule",
+		},
+		"Missing authority": {
+			config:      `{"primaryAuthorities": [{"onMultiple": "ignore"}]}`,
+			expectedErr: "rule 0 has no authority",
+		},
+		"Unknown field": {
+			config:      `{"primaryAuthority": [{"authority": "Smartlogic"}]}`,
+			expectedErr: `unknown field "primaryAuthority"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0600))
+
+			rules, err := LoadPrimaryAuthorityRules(path)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRules, rules)
+		})
+	}
+}
+
+func TestLoadPrimaryAuthorityRules_Defaults(t *testing.T) {
+	rules, err := LoadPrimaryAuthorityRules("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPrimaryAuthorityRules(), rules)
+
+	_, err = LoadPrimaryAuthorityRules(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
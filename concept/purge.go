@@ -0,0 +1,122 @@
+package concept
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+)
+
+// PurgeRule purges the concepts related to an updated concept of type ConceptType from the cache, as concepts of type TargetType.
+// The related concepts are the ones the concept has a relationship with the label Relationship to,
+// or the ones whose UUIDs are in the field Field of the JSON of the concept. A rule has either a relationship or a field.
+type PurgeRule struct {
+	ConceptType  string `json:"conceptType"`
+	Relationship string `json:"relationship,omitempty"`
+	Field        string `json:"field,omitempty"`
+	TargetType   string `json:"targetType"`
+}
+
+// PurgeRules lists which related concepts are purged along with an updated concept.
+type PurgeRules []PurgeRule
+
+// DefaultPurgeRules returns the rules used when none are configured.
+func DefaultPurgeRules() PurgeRules {
+	return PurgeRules{
+		{ConceptType: "FinancialInstrument", Field: "issuedBy", TargetType: "Organisation"},
+		{ConceptType: "Membership", Relationship: "HAS_MEMBER", TargetType: "Person"},
+	}
+}
+
+// LoadPurgeRules reads the rules from a JSON file, falling back to the default rules when no file is given.
+func LoadPurgeRules(path string) (PurgeRules, error) {
+	if path == "" {
+		return DefaultPurgeRules(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var config struct {
+		PurgeRules PurgeRules `json:"purgeRules"`
+	}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("decoding purge rules from %s: %w", path, err)
+	}
+	if err = config.PurgeRules.validate(); err != nil {
+		return nil, fmt.Errorf("invalid purge rules in %s: %w", path, err)
+	}
+	return config.PurgeRules, nil
+}
+
+func (r PurgeRules) validate() error {
+	for i, rule := range r {
+		switch {
+		case rule.ConceptType == "" || rule.TargetType == "":
+			return fmt.Errorf("rule %d needs a concept type and a target type", i)
+		case rule.Relationship == "" && rule.Field == "":
+			return fmt.Errorf("rule %d for %s has neither a relationship nor a field", i, rule.ConceptType)
+		case rule.Relationship != "" && rule.Field != "":
+			return fmt.Errorf("rule %d for %s has both a relationship and a field", i, rule.ConceptType)
+		}
+	}
+	return nil
+}
+
+// relatedPurge is a set of related concepts to purge as concepts of the same type.
+type relatedPurge struct {
+	UUIDs []string
+	Type  string
+}
+
+// relatedPurges evaluates the rules for the type of the concept. A rule which finds no related concept is returned without UUIDs.
+func (r PurgeRules) relatedPurges(concept ontology.CanonicalConcept) ([]relatedPurge, error) {
+	var doc map[string]interface{}
+	var purges []relatedPurge
+	for _, rule := range r {
+		if rule.ConceptType != concept.Type {
+			continue
+		}
+		purge := relatedPurge{Type: rule.TargetType}
+		if rule.Relationship != "" {
+			for _, rel := range concept.Relationships {
+				if rel.Label == rule.Relationship && rel.UUID != "" && !contains(rel.UUID, purge.UUIDs) {
+					purge.UUIDs = append(purge.UUIDs, rel.UUID)
+				}
+			}
+		} else {
+			if doc == nil {
+				var err error
+				if doc, err = toJSONDocument(concept); err != nil {
+					return nil, err
+				}
+			}
+			purge.UUIDs = fieldUUIDs(doc[rule.Field])
+		}
+		purges = append(purges, purge)
+	}
+	return purges, nil
+}
+
+// fieldUUIDs returns the UUIDs held by a field, which is either a single UUID or a list of them.
+func fieldUUIDs(value interface{}) []string {
+	var uuids []string
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			uuids = append(uuids, v)
+		}
+	case []interface{}:
+		for _, element := range v {
+			if uuid, ok := element.(string); ok && uuid != "" && !contains(uuid, uuids) {
+				uuids = append(uuids, uuid)
+			}
+		}
+	}
+	return uuids
+}
@@ -0,0 +1,205 @@
+package concept
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+func TestPurgeRules_RelatedPurges(t *testing.T) {
+	boardRoleRules := PurgeRules{{ConceptType: "BoardRole", Relationship: "HAS_ORGANISATION", TargetType: "Organisation"}}
+
+	testCases := map[string]struct {
+		rules          PurgeRules
+		concept        ontology.CanonicalConcept
+		expectedPurges []relatedPurge
+	}{
+		"FinancialInstrument purges its issuer": {
+			rules:          DefaultPurgeRules(),
+			concept:        ontology.CanonicalConcept{PrefUUID: "6562674e-dbfa-4cb0-85b2-41b0948b7cc2", Type: "FinancialInstrument", IssuedBy: "613b1f72-cc74-4d8f-9406-28fc91b82a2a"},
+			expectedPurges: []relatedPurge{{UUIDs: []string{"613b1f72-cc74-4d8f-9406-28fc91b82a2a"}, Type: "Organisation"}},
+		},
+		"FinancialInstrument without issuer": {
+			rules:          DefaultPurgeRules(),
+			concept:        ontology.CanonicalConcept{PrefUUID: "6562674e-dbfa-4cb0-85b2-41b0948b7cc2", Type: "FinancialInstrument"},
+			expectedPurges: []relatedPurge{{Type: "Organisation"}},
+		},
+		"Membership purges its member": {
+			rules: DefaultPurgeRules(),
+			concept: ontology.CanonicalConcept{
+				PrefUUID: "ce922022-8114-11e8-8f42-da24cd01f044",
+				Type:     "Membership",
+				Relationships: []ontology.Relationship{
+					{UUID: "3b961db6-02c1-4fde-b96d-aefd339a02a6", Label: "HAS_MEMBER"},
+					{UUID: "613b1f72-cc74-4d8f-9406-28fc91b82a2a", Label: "HAS_ORGANISATION"},
+				},
+			},
+			expectedPurges: []relatedPurge{{UUIDs: []string{"3b961db6-02c1-4fde-b96d-aefd339a02a6"}, Type: "Person"}},
+		},
+		"Membership without member": {
+			rules:          DefaultPurgeRules(),
+			concept:        ontology.CanonicalConcept{PrefUUID: "ce922022-8114-11e8-8f42-da24cd01f044", Type: "Membership"},
+			expectedPurges: []relatedPurge{{Type: "Person"}},
+		},
+		"Other types purge nothing": {
+			rules:   DefaultPurgeRules(),
+			concept: ontology.CanonicalConcept{PrefUUID: "28090964-9997-4bc2-9638-7a11135aaff9", Type: "Person", IssuedBy: "613b1f72-cc74-4d8f-9406-28fc91b82a2a"},
+		},
+		"BoardRole purges its organisations once each": {
+			rules: boardRoleRules,
+			concept: ontology.CanonicalConcept{
+				PrefUUID: "344fdb1d-0585-31f7-814f-b478e54dbe1f",
+				Type:     "BoardRole",
+				Relationships: []ontology.Relationship{
+					{UUID: "613b1f72-cc74-4d8f-9406-28fc91b82a2a", Label: "HAS_ORGANISATION"},
+					{UUID: "3b961db6-02c1-4fde-b96d-aefd339a02a6", Label: "HAS_ORGANISATION"},
+					{UUID: "613b1f72-cc74-4d8f-9406-28fc91b82a2a", Label: "HAS_ORGANISATION"},
+				},
+			},
+			expectedPurges: []relatedPurge{{UUIDs: []string{"613b1f72-cc74-4d8f-9406-28fc91b82a2a", "3b961db6-02c1-4fde-b96d-aefd339a02a6"}, Type: "Organisation"}},
+		},
+		"Several rules for a type": {
+			rules: append(DefaultPurgeRules(), PurgeRule{ConceptType: "Membership", Relationship: "HAS_ORGANISATION", TargetType: "Organisation"}),
+			concept: ontology.CanonicalConcept{
+				PrefUUID: "ce922022-8114-11e8-8f42-da24cd01f044",
+				Type:     "Membership",
+				Relationships: []ontology.Relationship{
+					{UUID: "3b961db6-02c1-4fde-b96d-aefd339a02a6", Label: "HAS_MEMBER"},
+					{UUID: "613b1f72-cc74-4d8f-9406-28fc91b82a2a", Label: "HAS_ORGANISATION"},
+				},
+			},
+			expectedPurges: []relatedPurge{
+				{UUIDs: []string{"3b961db6-02c1-4fde-b96d-aefd339a02a6"}, Type: "Person"},
+				{UUIDs: []string{"613b1f72-cc74-4d8f-9406-28fc91b82a2a"}, Type: "Organisation"},
+			},
+		},
+		"No rules": {
+			concept: ontology.CanonicalConcept{PrefUUID: "6562674e-dbfa-4cb0-85b2-41b0948b7cc2", Type: "FinancialInstrument", IssuedBy: "613b1f72-cc74-4d8f-9406-28fc91b82a2a"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			purges, err := tc.rules.relatedPurges(tc.concept)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPurges, purges)
+		})
+	}
+}
+
+func TestFieldUUIDs(t *testing.T) {
+	testCases := map[string]struct {
+		value    interface{}
+		expected []string
+	}{
+		"Single UUID":   {value: "613b1f72-cc74-4d8f-9406-28fc91b82a2a", expected: []string{"613b1f72-cc74-4d8f-9406-28fc91b82a2a"}},
+		"Empty":         {value: ""},
+		"Missing field": {value: nil},
+		"List of UUIDs": {
+			value:    []interface{}{"613b1f72-cc74-4d8f-9406-28fc91b82a2a", "", "3b961db6-02c1-4fde-b96d-aefd339a02a6", "613b1f72-cc74-4d8f-9406-28fc91b82a2a"},
+			expected: []string{"613b1f72-cc74-4d8f-9406-28fc91b82a2a", "3b961db6-02c1-4fde-b96d-aefd339a02a6"},
+		},
+		"Not a UUID": {value: map[string]interface{}{"uuid": "613b1f72-cc74-4d8f-9406-28fc91b82a2a"}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fieldUUIDs(tc.value))
+		})
+	}
+}
+
+func TestLoadPurgeRules(t *testing.T) {
+	testCases := map[string]struct {
+		config        string
+		expectedRules PurgeRules
+		expectedErr   string
+	}{
+		"Valid rules": {
+			config: `{"purgeRules": [{"conceptType": "BoardRole", "relationship": "HAS_ORGANISATION", "targetType": "Organisation"}, {"conceptType": "FinancialInstrument", "field": "issuedBy", "targetType": "Organisation"}]}`,
+			expectedRules: PurgeRules{
+				{ConceptType: "BoardRole", Relationship: "HAS_ORGANISATION", TargetType: "Organisation"},
+				{ConceptType: "FinancialInstrument", Field: "issuedBy", TargetType: "Organisation"},
+			},
+		},
+		"No rules": {
+			config:        `{"purgeRules": []}`,
+			expectedRules: PurgeRules{},
+		},
+		"Missing target type": {
+			config:      `{"purgeRules": [{"conceptType": "BoardRole", "relationship": "HAS_ORGANISATION"}]}`,
+			expectedErr: "rule 0 needs a concept type and a target type",
+		},
+		"Neither relationship nor field": {
+			config:      `{"purgeRules": [{"conceptType": "BoardRole", "targetType": "Organisation"}]}`,
+			expectedErr: "rule 0 for BoardRole has neither a relationship nor a field",
+		},
+		"Both relationship and field": {
+			config:      `{"purgeRules": [{"conceptType": "BoardRole", "relationship": "HAS_ORGANISATION", "field": "issuedBy", "targetType": "Organisation"}]}`,
+			expectedErr: "rule 0 for BoardRole has both a relationship and a field",
+		},
+		"Unknown field": {
+			config:      `{"purgeRules": [{"conceptType": "BoardRole", "label": "HAS_ORGANISATION", "targetType": "Organisation"}]}`,
+			expectedErr: `unknown field "label"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "purge.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0600))
+
+			rules, err := LoadPurgeRules(path)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRules, rules)
+		})
+	}
+}
+
+func TestLoadPurgeRules_Defaults(t *testing.T) {
+	rules, err := LoadPurgeRules("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPurgeRules(), rules)
+
+	_, err = LoadPurgeRules(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestAggregateService_ProcessMessage_PurgeRules(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.concordances = &mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{
+			"344fdb1d-0585-31f7-814f-b478e54dbe1f": {{UUID: "344fdb1d-0585-31f7-814f-b478e54dbe1f", Authority: "Smartlogic"}},
+		},
+	}
+	svc.nStore = &versionedS3Client{
+		versions: map[string][]conceptVersion{
+			"344fdb1d-0585-31f7-814f-b478e54dbe1f": {{transactionID: "tid_sl", concept: ontology.SourceConcept{
+				UUID:          "344fdb1d-0585-31f7-814f-b478e54dbe1f",
+				PrefLabel:     "Chair",
+				Authority:     "Smartlogic",
+				Type:          "BoardRole",
+				Relationships: []ontology.Relationship{{UUID: "613b1f72-cc74-4d8f-9406-28fc91b82a2a", Label: "HAS_ORGANISATION"}},
+			}}},
+		},
+	}
+	svc.purgeRules = PurgeRules{{ConceptType: "BoardRole", Relationship: "HAS_ORGANISATION", TargetType: "Organisation"}}
+
+	err := svc.ProcessMessage(context.Background(), "344fdb1d-0585-31f7-814f-b478e54dbe1f", "")
+	assert.NoError(t, err)
+	mockWriter := svc.httpClient.(*mockHTTPClient)
+	assert.Contains(t, mockWriter.called, "varnish-purger/purge?target=%2Fthings%2F613b1f72-cc74-4d8f-9406-28fc91b82a2a"+
+		"&target=%2Fconcepts%2F613b1f72-cc74-4d8f-9406-28fc91b82a2a"+
+		"&target=%2Forganisations%2F613b1f72-cc74-4d8f-9406-28fc91b82a2a", "the organisation of the board role should be purged")
+}
@@ -0,0 +1,137 @@
+package concept
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+// PublicationRoute tells which bucket the concepts of a publication are stored in.
+// They are read from under Prefix in the bucket, or from its root when there is no prefix.
+type PublicationRoute struct {
+	Publication string `json:"publication"`
+	Bucket      string `json:"bucket"`
+	Region      string `json:"region"`
+	Prefix      string `json:"prefix,omitempty"`
+}
+
+// LoadPublicationRoutes reads the publication routes from a JSON file. There are no routes when no file is given.
+func LoadPublicationRoutes(path string) ([]PublicationRoute, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var config struct {
+		Routes []PublicationRoute `json:"routes"`
+	}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid publication routes in %s: %w", path, err)
+	}
+
+	publications := map[string]bool{}
+	for i, route := range config.Routes {
+		switch {
+		case route.Publication == "":
+			return nil, fmt.Errorf("invalid publication routes in %s: route %d has no publication", path, i)
+		case route.Bucket == "" || route.Region == "":
+			return nil, fmt.Errorf("invalid publication routes in %s: route for publication %s needs a bucket and a region", path, route.Publication)
+		case publications[route.Publication]:
+			return nil, fmt.Errorf("invalid publication routes in %s: publication %s has more than one route", path, route.Publication)
+		}
+		publications[route.Publication] = true
+	}
+	return config.Routes, nil
+}
+
+// PublicationStore is the store the concepts of a publication are read from.
+type PublicationStore struct {
+	Route PublicationRoute
+	Store normalisedClient
+}
+
+// publicationRouter reads the concepts of each publication from the store of its route.
+// Publications without a route are read from the default store, from under a directory named after the publication.
+type publicationRouter struct {
+	defaultStore normalisedClient
+	routes       map[string]PublicationStore
+}
+
+func newPublicationRouter(defaultStore normalisedClient, stores []PublicationStore) *publicationRouter {
+	routes := make(map[string]PublicationStore, len(stores))
+	for _, store := range stores {
+		routes[store.Route.Publication] = store
+	}
+	return &publicationRouter{defaultStore: defaultStore, routes: routes}
+}
+
+// route returns the store of the publication and the directory its concepts are in.
+func (r *publicationRouter) route(publication string) (normalisedClient, string) {
+	if routed, ok := r.routes[publication]; ok {
+		return routed.Store, routed.Route.Prefix
+	}
+	return r.defaultStore, publication
+}
+
+func (r *publicationRouter) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+	store, prefix := r.route(publication)
+	return store.GetConceptAndTransactionID(ctx, prefix, UUID)
+}
+
+func (r *publicationRouter) GetConceptAndTransactionIDAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, ontology.SourceConcept, string, error) {
+	store, prefix := r.route(publication)
+	versioned, ok := store.(versionedClient)
+	if !ok {
+		return false, ontology.SourceConcept{}, "", failure.Wrap(failure.Permanent, ErrVersionsNotSupported)
+	}
+	return versioned.GetConceptAndTransactionIDAsOf(ctx, prefix, UUID, asOf)
+}
+
+// ObjectKey returns the key of the concept in the store of its publication, or an empty string if the store cannot tell.
+func (r *publicationRouter) ObjectKey(publication string, UUID string) string {
+	store, prefix := r.route(publication)
+	keyed, ok := store.(keyedClient)
+	if !ok {
+		return ""
+	}
+	return keyed.ObjectKey(prefix, UUID)
+}
+
+// Healthcheck checks the default store. The stores of the routes have their own checks.
+func (r *publicationRouter) Healthcheck() fthealth.Check {
+	return r.defaultStore.Healthcheck()
+}
+
+// routeHealthchecks returns a check for the store of each route, naming the publication it serves.
+func (r *publicationRouter) routeHealthchecks() []fthealth.Check {
+	publications := make([]string, 0, len(r.routes))
+	for publication := range r.routes {
+		publications = append(publications, publication)
+	}
+	sort.Strings(publications)
+
+	var checks []fthealth.Check
+	for _, publication := range publications {
+		routed := r.routes[publication]
+		check := routed.Store.Healthcheck()
+		check.Name = fmt.Sprintf("%s for publication %s", check.Name, publication)
+		check.TechnicalSummary = fmt.Sprintf("%s. The concepts of publication %s are read from bucket %s in %s", check.TechnicalSummary, publication, routed.Route.Bucket, routed.Route.Region)
+		checks = append(checks, check)
+	}
+	return checks
+}
@@ -0,0 +1,163 @@
+package concept
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/cm-graph-ontology/v2/transform"
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPublicationRoutes(t *testing.T) {
+	testCases := map[string]struct {
+		config         string
+		expectedRoutes []PublicationRoute
+		expectedErr    string
+	}{
+		"Valid routes": {
+			config: `{"routes": [
+				{"publication": "8e6c705e-1132-42a2-8db0-c295e29e8658", "bucket": "sv-concepts", "region": "us-east-1", "prefix": "concepts"},
+				{"publication": "Generic", "bucket": "generic-concepts", "region": "eu-west-1"}
+			]}`,
+			expectedRoutes: []PublicationRoute{
+				{Publication: "8e6c705e-1132-42a2-8db0-c295e29e8658", Bucket: "sv-concepts", Region: "us-east-1", Prefix: "concepts"},
+				{Publication: "Generic", Bucket: "generic-concepts", Region: "eu-west-1"},
+			},
+		},
+		"Missing publication": {
+			config:      `{"routes": [{"bucket": "sv-concepts", "region": "us-east-1"}]}`,
+			expectedErr: "route 0 has no publication",
+		},
+		"Missing region": {
+			config:      `{"routes": [{"publication": "Generic", "bucket": "generic-concepts"}]}`,
+			expectedErr: "route for publication Generic needs a bucket and a region",
+		},
+		"Duplicate publication": {
+			config: `{"routes": [
+				{"publication": "Generic", "bucket": "generic-concepts", "region": "eu-west-1"},
+				{"publication": "Generic", "bucket": "other-concepts", "region": "eu-west-1"}
+			]}`,
+			expectedErr: "publication Generic has more than one route",
+		},
+		"Unknown field": {
+			config:      `{"routes": [{"publication": "Generic", "bucketName": "generic-concepts", "region": "eu-west-1"}]}`,
+			expectedErr: `unknown field "bucketName"`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "routes.json")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0600))
+
+			routes, err := LoadPublicationRoutes(path)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRoutes, routes)
+		})
+	}
+}
+
+func TestLoadPublicationRoutes_NoFile(t *testing.T) {
+	routes, err := LoadPublicationRoutes("")
+	assert.NoError(t, err)
+	assert.Empty(t, routes)
+}
+
+func newRoutedS3Client(key string, prefLabel string) *mockS3Client {
+	return &mockS3Client{
+		concepts: map[string]struct {
+			transactionID string
+			concept       transform.OldConcept
+		}{
+			key: {
+				transactionID: "tid_" + prefLabel,
+				concept: transform.OldConcept{
+					UUID:      "f3633e04-2ee3-48ce-8081-37734dab3fdc",
+					PrefLabel: prefLabel,
+					Authority: "Smartlogic",
+					Type:      "TestConcept",
+				},
+			},
+		},
+	}
+}
+
+func TestPublicationRouter_GetConceptAndTransactionID(t *testing.T) {
+	router := newPublicationRouter(
+		newRoutedS3Client("929da855-c1ba-4576-89c1-5c3ec9e4c6ef/f3633e04-2ee3-48ce-8081-37734dab3fdc", "Default"),
+		[]PublicationStore{
+			{
+				Route: PublicationRoute{Publication: "8e6c705e-1132-42a2-8db0-c295e29e8658", Bucket: "sv-concepts", Region: "us-east-1", Prefix: "concepts"},
+				Store: newRoutedS3Client("concepts/f3633e04-2ee3-48ce-8081-37734dab3fdc", "Prefixed"),
+			},
+			{
+				Route: PublicationRoute{Publication: "Generic", Bucket: "generic-concepts", Region: "eu-west-1"},
+				Store: newRoutedS3Client("f3633e04-2ee3-48ce-8081-37734dab3fdc", "Root"),
+			},
+		},
+	)
+
+	testCases := map[string]string{
+		"929da855-c1ba-4576-89c1-5c3ec9e4c6ef": "Default",
+		"8e6c705e-1132-42a2-8db0-c295e29e8658": "Prefixed",
+		"Generic":                              "Root",
+	}
+	for publication, expectedLabel := range testCases {
+		t.Run(publication, func(t *testing.T) {
+			found, concept, tid, err := router.GetConceptAndTransactionID(context.Background(), publication, "f3633e04-2ee3-48ce-8081-37734dab3fdc")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, expectedLabel, concept.PrefLabel)
+			assert.Equal(t, "tid_"+expectedLabel, tid)
+		})
+	}
+
+	_, _, _, err := router.GetConceptAndTransactionIDAsOf(context.Background(), "Generic", "f3633e04-2ee3-48ce-8081-37734dab3fdc", time.Now())
+	assert.ErrorIs(t, err, ErrVersionsNotSupported)
+}
+
+func TestAggregateService_Healthchecks_PublicationRoutes(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	s3Check := &namedHealthStore{name: "Check connectivity to S3 bucket", summary: "Cannot connect to S3 bucket"}
+	svc.externalNormalisedStore = newPublicationRouter(svc.externalNormalisedStore.defaultStore, []PublicationStore{
+		{
+			Route: PublicationRoute{Publication: "Generic", Bucket: "generic-concepts", Region: "eu-west-1"},
+			Store: s3Check,
+		},
+		{
+			Route: PublicationRoute{Publication: "8e6c705e-1132-42a2-8db0-c295e29e8658", Bucket: "sv-concepts", Region: "us-east-1"},
+			Store: s3Check,
+		},
+	})
+
+	var routeChecks []fthealth.Check
+	for _, check := range svc.Healthchecks() {
+		if strings.Contains(check.Name, "for publication") {
+			routeChecks = append(routeChecks, check)
+		}
+	}
+	require.Len(t, routeChecks, 2)
+	assert.Equal(t, "Check connectivity to S3 bucket for publication 8e6c705e-1132-42a2-8db0-c295e29e8658", routeChecks[0].Name)
+	assert.Equal(t, "Cannot connect to S3 bucket. The concepts of publication 8e6c705e-1132-42a2-8db0-c295e29e8658 are read from bucket sv-concepts in us-east-1", routeChecks[0].TechnicalSummary)
+	assert.Equal(t, "Check connectivity to S3 bucket for publication Generic", routeChecks[1].Name)
+}
+
+type namedHealthStore struct {
+	versionedS3Client
+	name    string
+	summary string
+}
+
+func (s *namedHealthStore) Healthcheck() fthealth.Check {
+	return fthealth.Check{Name: s.name, TechnicalSummary: s.summary}
+}
@@ -2,13 +2,18 @@ package concept
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
 	"github.com/stretchr/testify/mock"
 
 	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
 	"github.com/Financial-Times/cm-graph-ontology/v2/transform"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
 type mockS3Client struct {
@@ -42,6 +47,15 @@ func (s *mockS3Client) GetConceptAndTransactionID(ctx context.Context, publicati
 	}
 	return true, concept, c.transactionID, s.err
 }
+
+// ObjectKey returns the key the concept is looked up with.
+func (s *mockS3Client) ObjectKey(publication string, UUID string) string {
+	if publication != "" {
+		return strings.Join([]string{publication, UUID}, "/")
+	}
+	return UUID
+}
+
 func (s *mockS3Client) Healthcheck() fthealth.Check {
 	return fthealth.Check{
 		Checker: func() (string, error) {
@@ -49,3 +63,113 @@ func (s *mockS3Client) Healthcheck() fthealth.Check {
 		},
 	}
 }
+
+// concurrentS3Client returns a concept for any UUID after a delay, or once release is closed when it is set,
+// keeping track of how many requests it serves at once. The UUID of every request is sent to started when it is set.
+type concurrentS3Client struct {
+	delay     time.Duration
+	started   chan string
+	release   chan struct{}
+	errors    map[string]error
+	running   int32
+	maxActive int32
+}
+
+func (s *concurrentS3Client) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+	running := atomic.AddInt32(&s.running, 1)
+	defer atomic.AddInt32(&s.running, -1)
+	for {
+		maxActive := atomic.LoadInt32(&s.maxActive)
+		if running <= maxActive || atomic.CompareAndSwapInt32(&s.maxActive, maxActive, running) {
+			break
+		}
+	}
+	if s.started != nil {
+		s.started <- UUID
+	}
+
+	if err, ok := s.errors[UUID]; ok {
+		return false, ontology.SourceConcept{}, "", err
+	}
+	wait := time.After(s.delay)
+	if s.release != nil {
+		wait = nil
+	}
+	select {
+	case <-wait:
+	case <-s.release:
+	case <-ctx.Done():
+		return false, ontology.SourceConcept{}, "", ctx.Err()
+	}
+	return true, ontology.SourceConcept{UUID: UUID}, "tid_" + UUID, nil
+}
+
+func (s *concurrentS3Client) Healthcheck() fthealth.Check {
+	return fthealth.Check{}
+}
+
+type conceptVersion struct {
+	created       time.Time
+	concept       ontology.SourceConcept
+	transactionID string
+}
+
+// versionedS3Client serves the latest version of each concept created by the requested time.
+type versionedS3Client struct {
+	versions map[string][]conceptVersion
+}
+
+func (s *versionedS3Client) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+	return s.GetConceptAndTransactionIDAsOf(ctx, publication, UUID, time.Now())
+}
+
+func (s *versionedS3Client) GetConceptAndTransactionIDAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, ontology.SourceConcept, string, error) {
+	var latest *conceptVersion
+	for i, v := range s.versions[UUID] {
+		if !v.created.After(asOf) && (latest == nil || v.created.After(latest.created)) {
+			latest = &s.versions[UUID][i]
+		}
+	}
+	if latest == nil {
+		return false, ontology.SourceConcept{}, "", nil
+	}
+	return true, latest.concept, latest.transactionID, nil
+}
+
+func (s *versionedS3Client) Healthcheck() fthealth.Check {
+	return fthealth.Check{}
+}
+
+type storedDocument struct {
+	transactionID string
+	body          string
+}
+
+// documentS3Client serves concepts from their JSON documents as they would be stored in S3, including invalid ones.
+type documentS3Client struct {
+	documents map[string]storedDocument
+}
+
+func (s *documentS3Client) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+	found, document, tid, err := s.GetConceptDocument(ctx, publication, UUID)
+	if err != nil || !found {
+		return found, ontology.SourceConcept{}, "", err
+	}
+	var concept ontology.SourceConcept
+	if err = json.Unmarshal(document, &concept); err != nil {
+		return true, ontology.SourceConcept{}, "", failure.Wrap(failure.Validation, err)
+	}
+	return true, concept, tid, nil
+}
+
+func (s *documentS3Client) GetConceptDocument(ctx context.Context, publication string, UUID string) (bool, []byte, string, error) {
+	d, ok := s.documents[UUID]
+	if !ok {
+		return false, nil, "", nil
+	}
+	return true, []byte(d.body), d.transactionID, nil
+}
+
+func (s *documentS3Client) Healthcheck() fthealth.Check {
+	return fthealth.Check{}
+}
@@ -0,0 +1,157 @@
+package concept
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/go-logger"
+)
+
+// keyedSerialiser runs at most one function at a time for each key.
+// While a function runs, later calls for the same key are coalesced into a single pending run, which starts once the
+// running one has finished and whose result is shared by every coalesced caller still waiting for it.
+// The pending run is given the update of every coalesced caller merged together, so no caller loses its force or bookmark.
+type keyedSerialiser struct {
+	sync.Mutex
+	keys map[string]*keyState
+}
+
+type keyState struct {
+	pending *serialisedCall
+}
+
+type serialisedCall struct {
+	waiters       []*serialisedWaiter
+	cancel        context.CancelFunc
+	done          chan struct{}
+	transactionID string
+	err           error
+}
+
+// serialisedWaiter is a caller waiting for a pending run, along with its update and the function it would run.
+type serialisedWaiter struct {
+	ctx    context.Context
+	update update
+	fn     serialisedFunc
+}
+
+type serialisedFunc func(ctx context.Context, waited bool, u update) (string, error)
+
+// update is what a caller asks of a serialised run.
+type update struct {
+	// Bookmark is the Neo4j bookmark the concordances must be read at, if any.
+	Bookmark string
+	// Force writes the concept even when it is unchanged since it was last written.
+	Force bool
+}
+
+// merge returns the update of a run coalescing u and a later call: it is forced if either is,
+// and uses the bookmark of the later call unless it has none, as later calls carry the newer bookmarks.
+func (u update) merge(later update) update {
+	merged := update{Bookmark: u.Bookmark, Force: u.Force || later.Force}
+	if later.Bookmark != "" {
+		merged.Bookmark = later.Bookmark
+	}
+	return merged
+}
+
+func newKeyedSerialiser() *keyedSerialiser {
+	return &keyedSerialiser{keys: map[string]*keyState{}}
+}
+
+// Do runs fn with u once nothing else is running for key and returns its transaction ID and error.
+// waited tells fn whether it had to wait for another run for the same key, in which case any data read before calling Do may be stale.
+// A caller which is coalesced into a pending run does not run its own fn: the pending run uses the fn of the latest caller still waiting
+// with the updates of every caller still waiting merged together, and a context which is only cancelled once every coalesced caller
+// has stopped waiting or the earliest of their deadlines has passed.
+func (k *keyedSerialiser) Do(ctx context.Context, key string, u update, fn serialisedFunc) (string, error) {
+	k.Lock()
+	state, running := k.keys[key]
+	if !running {
+		k.keys[key] = &keyState{}
+		k.Unlock()
+		defer k.next(key)
+		return fn(ctx, false, u)
+	}
+	if state.pending == nil {
+		state.pending = &serialisedCall{done: make(chan struct{})}
+	}
+	call := state.pending
+	waiter := &serialisedWaiter{ctx: ctx, update: u, fn: fn}
+	call.waiters = append(call.waiters, waiter)
+	k.Unlock()
+
+	select {
+	case <-call.done:
+		return call.transactionID, call.err
+	case <-ctx.Done():
+		k.leave(call, waiter)
+		return "", ctx.Err()
+	}
+}
+
+// leave removes a caller which stopped waiting from its call, so its fn is not run, and cancels the run once nobody waits for it.
+func (k *keyedSerialiser) leave(call *serialisedCall, waiter *serialisedWaiter) {
+	k.Lock()
+	defer k.Unlock()
+	for i, w := range call.waiters {
+		if w == waiter {
+			call.waiters = append(call.waiters[:i:i], call.waiters[i+1:]...)
+			break
+		}
+	}
+	if len(call.waiters) == 0 && call.cancel != nil {
+		call.cancel()
+	}
+}
+
+// next starts the pending run for key, if anybody is still waiting for it, or forgets the key otherwise.
+func (k *keyedSerialiser) next(key string) {
+	k.Lock()
+	defer k.Unlock()
+	state := k.keys[key]
+	call := state.pending
+	state.pending = nil
+	var latest *serialisedWaiter
+	var merged update
+	var deadline time.Time
+	if call != nil {
+		for _, w := range call.waiters {
+			if w.ctx.Err() != nil {
+				continue
+			}
+			if latest == nil {
+				merged = w.update
+			} else {
+				merged = merged.merge(w.update)
+			}
+			if d, ok := w.ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+				deadline = d
+			}
+			latest = w
+		}
+	}
+	if latest == nil {
+		delete(k.keys, key)
+		return
+	}
+	if len(call.waiters) > 1 {
+		logger.WithField("key", key).Debugf("Coalesced %d pending updates into one", len(call.waiters))
+	}
+	// the run outlives the caller whose fn it uses, but not the earliest deadline of the callers waiting for it
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if deadline.IsZero() {
+		ctx, cancel = context.WithCancel(context.WithoutCancel(latest.ctx))
+	} else {
+		ctx, cancel = context.WithDeadline(context.WithoutCancel(latest.ctx), deadline)
+	}
+	call.cancel = cancel
+	go func() {
+		defer k.next(key)
+		call.transactionID, call.err = latest.fn(ctx, true, merged)
+		cancel()
+		close(call.done)
+	}()
+}
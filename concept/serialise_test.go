@@ -0,0 +1,327 @@
+package concept
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedSerialiser_RunsOneAtATimePerKey(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = serialiser.Do(context.Background(), "source:a", update{}, func(context.Context, bool, update) (string, error) {
+				current := atomic.AddInt32(&running, 1)
+				for {
+					highest := atomic.LoadInt32(&maxRunning)
+					if current <= highest || atomic.CompareAndSwapInt32(&maxRunning, highest, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return "", nil
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxRunning)
+	assert.Eventually(t, func() bool {
+		serialiser.Lock()
+		defer serialiser.Unlock()
+		return len(serialiser.keys) == 0
+	}, time.Second, time.Millisecond, "keys should be forgotten once nothing is pending")
+}
+
+func TestKeyedSerialiser_DifferentKeysRunConcurrently(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _ = serialiser.Do(context.Background(), "concept:a", update{}, func(context.Context, bool, update) (string, error) {
+			close(started)
+			<-release
+			return "", nil
+		})
+	}()
+	<-started
+
+	tid, err := serialiser.Do(context.Background(), "concept:b", update{}, func(_ context.Context, waited bool, _ update) (string, error) {
+		assert.False(t, waited)
+		return "tid_b", nil
+	})
+	close(release)
+	assert.NoError(t, err)
+	assert.Equal(t, "tid_b", tid)
+}
+
+func TestKeyedSerialiser_CoalescesPendingCalls(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _ = serialiser.Do(context.Background(), "concept:a", update{}, func(context.Context, bool, update) (string, error) {
+			close(started)
+			<-release
+			return "tid_first", nil
+		})
+	}()
+	<-started
+
+	var runs int32
+	results := make(chan string, 3)
+	var wg sync.WaitGroup
+	for i, tid := range []string{"tid_1", "tid_2", "tid_3"} {
+		wg.Add(1)
+		go func(tid string) {
+			defer wg.Done()
+			result, err := serialiser.Do(context.Background(), "concept:a", update{}, func(_ context.Context, waited bool, _ update) (string, error) {
+				assert.True(t, waited)
+				atomic.AddInt32(&runs, 1)
+				return tid, nil
+			})
+			assert.NoError(t, err)
+			results <- result
+		}(tid)
+		// wait for the caller to join the pending run, so the callers join in order
+		joined := i + 1
+		assert.Eventually(t, func() bool {
+			serialiser.Lock()
+			defer serialiser.Unlock()
+			pending := serialiser.keys["concept:a"].pending
+			return pending != nil && len(pending.waiters) == joined
+		}, time.Second, time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+
+	assert.Equal(t, int32(1), runs)
+	for result := range results {
+		assert.Equal(t, "tid_3", result, "the pending run should use the latest caller")
+	}
+}
+
+func TestKeyedSerialiser_StopsWaitingWhenContextIsDone(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	go func() {
+		_, _ = serialiser.Do(context.Background(), "source:a", update{}, func(context.Context, bool, update) (string, error) {
+			close(started)
+			<-release
+			return "", nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := serialiser.Do(ctx, "source:a", update{}, func(context.Context, bool, update) (string, error) {
+		return "", nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// holdKey runs a function for key until the returned channel is closed.
+func holdKey(serialiser *keyedSerialiser, key string) chan struct{} {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_, _ = serialiser.Do(context.Background(), key, update{}, func(context.Context, bool, update) (string, error) {
+			close(started)
+			<-release
+			return "tid_first", nil
+		})
+	}()
+	<-started
+	return release
+}
+
+func waitForWaiters(t *testing.T, serialiser *keyedSerialiser, key string, waiters int) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		serialiser.Lock()
+		defer serialiser.Unlock()
+		pending := serialiser.keys[key].pending
+		return pending != nil && len(pending.waiters) == waiters
+	}, time.Second, time.Millisecond)
+}
+
+func TestKeyedSerialiser_CancelledCallerDoesNotRun(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	release := holdKey(serialiser, "concept:a")
+
+	result := make(chan string, 1)
+	go func() {
+		tid, err := serialiser.Do(context.Background(), "concept:a", update{}, func(ctx context.Context, _ bool, _ update) (string, error) {
+			return "tid_waiting", ctx.Err()
+		})
+		assert.NoError(t, err)
+		result <- tid
+	}()
+	waitForWaiters(t, serialiser, "concept:a", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := serialiser.Do(ctx, "concept:a", update{}, func(context.Context, bool, update) (string, error) {
+			t.Error("the function of a caller which stopped waiting should not run")
+			return "tid_cancelled", nil
+		})
+		cancelled <- err
+	}()
+	waitForWaiters(t, serialiser, "concept:a", 2)
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	waitForWaiters(t, serialiser, "concept:a", 1)
+
+	close(release)
+	assert.Equal(t, "tid_waiting", <-result)
+}
+
+func TestKeyedSerialiser_RunOutlivesCancelledCallerWhileOthersWait(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	release := holdKey(serialiser, "concept:a")
+
+	result := make(chan string, 1)
+	go func() {
+		tid, err := serialiser.Do(context.Background(), "concept:a", update{}, func(context.Context, bool, update) (string, error) {
+			return "tid_earlier", nil
+		})
+		assert.NoError(t, err)
+		result <- tid
+	}()
+	waitForWaiters(t, serialiser, "concept:a", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	running := make(chan struct{})
+	resume := make(chan struct{})
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := serialiser.Do(ctx, "concept:a", update{}, func(runCtx context.Context, _ bool, _ update) (string, error) {
+			close(running)
+			<-resume
+			return "tid_latest", runCtx.Err()
+		})
+		cancelled <- err
+	}()
+	waitForWaiters(t, serialiser, "concept:a", 2)
+
+	close(release)
+	<-running
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+	close(resume)
+	assert.Equal(t, "tid_latest", <-result, "the run should not be cancelled while a caller is still waiting for it")
+}
+
+func TestKeyedSerialiser_CancelsRunOnceNobodyWaits(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	release := holdKey(serialiser, "concept:a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	running := make(chan struct{})
+	runErr := make(chan error, 1)
+	go func() {
+		_, _ = serialiser.Do(ctx, "concept:a", update{}, func(runCtx context.Context, _ bool, _ update) (string, error) {
+			close(running)
+			<-runCtx.Done()
+			runErr <- runCtx.Err()
+			return "", runCtx.Err()
+		})
+	}()
+	waitForWaiters(t, serialiser, "concept:a", 1)
+
+	close(release)
+	<-running
+	cancel()
+	assert.ErrorIs(t, <-runErr, context.Canceled)
+}
+
+func TestKeyedSerialiser_MergesCoalescedUpdates(t *testing.T) {
+	testCases := map[string]struct {
+		updates  []update
+		expected update
+	}{
+		"Forced by an earlier caller": {
+			updates:  []update{{Force: true}, {Bookmark: "bookmark_1"}},
+			expected: update{Bookmark: "bookmark_1", Force: true},
+		},
+		"Newest bookmark": {
+			updates:  []update{{Bookmark: "bookmark_1"}, {Bookmark: "bookmark_2"}},
+			expected: update{Bookmark: "bookmark_2"},
+		},
+		"Latest caller without bookmark": {
+			updates:  []update{{Bookmark: "bookmark_1"}, {}},
+			expected: update{Bookmark: "bookmark_1"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			serialiser := newKeyedSerialiser()
+			release := holdKey(serialiser, "source:a")
+
+			runs := make(chan update, len(tc.updates))
+			var wg sync.WaitGroup
+			for i, u := range tc.updates {
+				wg.Add(1)
+				go func(u update) {
+					defer wg.Done()
+					_, err := serialiser.Do(context.Background(), "source:a", u, func(_ context.Context, _ bool, merged update) (string, error) {
+						runs <- merged
+						return "", nil
+					})
+					assert.NoError(t, err)
+				}(u)
+				waitForWaiters(t, serialiser, "source:a", i+1)
+			}
+			close(release)
+			wg.Wait()
+			close(runs)
+
+			assert.Len(t, runs, 1)
+			assert.Equal(t, tc.expected, <-runs)
+		})
+	}
+}
+
+func TestKeyedSerialiser_RunKeepsEarliestDeadline(t *testing.T) {
+	serialiser := newKeyedSerialiser()
+	release := holdKey(serialiser, "concept:a")
+
+	earliest := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), earliest)
+	defer cancel()
+	go func() {
+		_, _ = serialiser.Do(ctx, "concept:a", update{}, func(context.Context, bool, update) (string, error) {
+			return "", nil
+		})
+	}()
+	waitForWaiters(t, serialiser, "concept:a", 1)
+
+	deadlines := make(chan time.Time, 1)
+	go func() {
+		laterCtx, laterCancel := context.WithTimeout(context.Background(), time.Hour)
+		defer laterCancel()
+		_, _ = serialiser.Do(laterCtx, "concept:a", update{}, func(runCtx context.Context, _ bool, _ update) (string, error) {
+			deadline, _ := runCtx.Deadline()
+			deadlines <- deadline
+			return "", nil
+		})
+	}()
+	waitForWaiters(t, serialiser, "concept:a", 2)
+
+	close(release)
+	assert.Equal(t, earliest, <-deadlines)
+}
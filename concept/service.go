@@ -20,6 +20,7 @@ import (
 	"github.com/Financial-Times/cm-graph-ontology/v2/aggregate"
 
 	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 	"github.com/Financial-Times/aggregate-concept-transformer/kinesis"
 	"github.com/Financial-Times/aggregate-concept-transformer/sns"
 	"github.com/Financial-Times/aggregate-concept-transformer/sqs"
@@ -29,6 +30,14 @@ const (
 	thingsAPIEndpoint  = "/things"
 	conceptsAPIEnpoint = "/concepts"
 	lengthOfUUID       = 36
+
+	// defaults for how long a failed update stays hidden on the queue before it is retried
+	defaultRetryBackoff     = 10 * time.Second
+	defaultMaxRetryBackoff  = 15 * time.Minute
+	defaultUnavailablePause = 30 * time.Second
+
+	// how often a receiver checks whether it can resume consuming when the service is unhealthy or paused
+	consumptionRecheckInterval = time.Second
 )
 
 var (
@@ -47,10 +56,11 @@ var (
 
 type systemHealth struct {
 	sync.RWMutex
-	healthy  bool
-	shutdown bool
-	feedback <-chan bool
-	done     <-chan struct{}
+	healthy     bool
+	shutdown    bool
+	pausedUntil time.Time
+	feedback    <-chan bool
+	done        <-chan struct{}
 }
 
 func (r *systemHealth) isGood() bool {
@@ -65,6 +75,21 @@ func (r *systemHealth) isShuttingDown() bool {
 	return r.shutdown
 }
 
+func (r *systemHealth) isPaused() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return time.Now().Before(r.pausedUntil)
+}
+
+// pauseUntil stops the workers from consuming messages until the given time, without ever shortening an existing pause.
+func (r *systemHealth) pauseUntil(until time.Time) {
+	r.Lock()
+	defer r.Unlock()
+	if until.After(r.pausedUntil) {
+		r.pausedUntil = until
+	}
+}
+
 func (r *systemHealth) processChannel() {
 	for {
 		select {
@@ -89,9 +114,33 @@ type normalisedClient interface {
 	Healthcheck() fthealth.Check
 }
 
+// versionedClient is implemented by the normalised stores which can read concepts as they were at a point in time.
+type versionedClient interface {
+	GetConceptAndTransactionIDAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, ontology.SourceConcept, string, error)
+}
+
+// documentClient is implemented by the normalised stores which can return the JSON document of a concept as it is stored,
+// so that it is validated before being decoded into a source concept.
+type documentClient interface {
+	GetConceptDocument(ctx context.Context, publication string, UUID string) (bool, []byte, string, error)
+}
+
+// versionedDocumentClient is implemented by the normalised stores which can return the JSON document of a concept as it was at a point in time.
+type versionedDocumentClient interface {
+	GetConceptDocumentAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, []byte, string, error)
+}
+
+// keyedClient is implemented by the normalised stores which can tell where they keep a concept, e.g. its S3 object key.
+type keyedClient interface {
+	ObjectKey(publication string, UUID string) string
+}
+
+// ErrVersionsNotSupported is returned when aggregating a concept at a point in time from a store which does not keep old versions.
+var ErrVersionsNotSupported = errors.New("the concept store does not support reading concepts at a point in time")
+
 type AggregateService struct {
 	nStore                          normalisedClient
-	externalNormalisedStore         normalisedClient
+	externalNormalisedStore         *publicationRouter
 	concordances                    concordances.Client
 	conceptUpdatesSqs               sqs.Client
 	eventsSns                       sns.Client
@@ -101,14 +150,29 @@ type AggregateService struct {
 	elasticsearchWriterAddress      string
 	httpClient                      httpClient
 	typesToPurgeFromPublicEndpoints []string
+	purgeRules                      PurgeRules
+	elasticsearchPolicy             *ElasticsearchPolicySource
+	authorityPrecedence             authorityPrecedence
+	primaryAuthorityRules           PrimaryAuthorityRules
+	canonicalValidators             CanonicalValidators
 	health                          *systemHealth
 	processTimeout                  time.Duration
+	visibilityTimeout               time.Duration
+	sourceFetchConcurrency          int
+	sourceValidation                ValidationMode
+	serialiser                      *keyedSerialiser
+	conflicts                       *conflictStore
+	contentHashes                   ContentHashStore
 	readOnly                        bool
+	retryBackoff                    time.Duration
+	maxRetryBackoff                 time.Duration
+	unavailablePause                time.Duration
 }
 
 func NewService(
 	S3Client normalisedClient,
 	ExternalS3Client normalisedClient,
+	publicationStores []PublicationStore,
 	conceptUpdatesSQSClient sqs.Client,
 	eventsSNSClient sns.Client,
 	concordancesClient concordances.Client,
@@ -117,11 +181,20 @@ func NewService(
 	elasticsearchAddress string,
 	varnishPurgerAddress string,
 	typesToPurgeFromPublicEndpoints []string,
+	purgeRules PurgeRules,
+	elasticsearchPolicy *ElasticsearchPolicySource,
+	authorityPrecedence []string,
+	primaryAuthorityRules PrimaryAuthorityRules,
+	canonicalValidators CanonicalValidators,
 	httpClient httpClient,
 	feedback <-chan bool,
 	done <-chan struct{},
 	processTimeout time.Duration,
+	visibilityTimeout time.Duration,
+	sourceFetchConcurrency int,
+	sourceValidation ValidationMode,
 	readOnly bool,
+	contentHashes ContentHashStore,
 ) *AggregateService {
 	health := &systemHealth{
 		healthy:  false, // Set to false. Once health check passes app will read from SQS
@@ -133,7 +206,7 @@ func NewService(
 
 	return &AggregateService{
 		nStore:                          S3Client,
-		externalNormalisedStore:         ExternalS3Client,
+		externalNormalisedStore:         newPublicationRouter(ExternalS3Client, publicationStores),
 		concordances:                    concordancesClient,
 		conceptUpdatesSqs:               conceptUpdatesSQSClient,
 		eventsSns:                       eventsSNSClient,
@@ -143,62 +216,150 @@ func NewService(
 		varnishPurgerAddress:            varnishPurgerAddress,
 		httpClient:                      httpClient,
 		typesToPurgeFromPublicEndpoints: typesToPurgeFromPublicEndpoints,
+		purgeRules:                      purgeRules,
+		elasticsearchPolicy:             elasticsearchPolicy,
+		authorityPrecedence:             newAuthorityPrecedence(authorityPrecedence),
+		primaryAuthorityRules:           primaryAuthorityRules,
+		canonicalValidators:             canonicalValidators,
 		health:                          health,
 		processTimeout:                  processTimeout,
+		visibilityTimeout:               visibilityTimeout,
+		sourceFetchConcurrency:          sourceFetchConcurrency,
+		sourceValidation:                sourceValidation,
+		serialiser:                      newKeyedSerialiser(),
+		conflicts:                       newConflictStore(),
+		contentHashes:                   contentHashes,
 		readOnly:                        readOnly,
+		retryBackoff:                    defaultRetryBackoff,
+		maxRetryBackoff:                 defaultMaxRetryBackoff,
+		unavailablePause:                defaultUnavailablePause,
 	}
 }
 
-func (s *AggregateService) ListenForNotifications(ctx context.Context, workerID int) {
+// ListenForNotifications consumes concept updates until the service shuts down.
+// Receivers poll SQS and feed a bounded channel consumed by a fixed pool of processors, so a slow update only holds up its own processor.
+// maxInFlight limits how many messages are held at once, whether they are waiting for a processor or being processed.
+func (s *AggregateService) ListenForNotifications(ctx context.Context, receivers int, processors int, maxInFlight int) {
 	if s.readOnly {
 		return
 	}
+	inFlight := make(chan struct{}, maxInFlight)
+	updates := make(chan sqs.ConceptUpdate, processors)
+
+	var processorsWG sync.WaitGroup
+	processorsWG.Add(processors)
+	for i := 0; i < processors; i++ {
+		go func() {
+			defer processorsWG.Done()
+			s.processConceptUpdates(updates, inFlight)
+		}()
+	}
+
+	var receiversWG sync.WaitGroup
+	receiversWG.Add(receivers)
+	for i := 0; i < receivers; i++ {
+		go func(receiverID int) {
+			defer receiversWG.Done()
+			s.receiveConceptUpdates(ctx, receiverID, updates, inFlight)
+		}(i)
+	}
+
+	receiversWG.Wait()
+	close(updates)
+	processorsWG.Wait()
+}
+
+func (s *AggregateService) receiveConceptUpdates(ctx context.Context, receiverID int, updates chan<- sqs.ConceptUpdate, inFlight chan struct{}) {
 	listenCtx, listenCancel := context.WithCancel(context.Background())
 	defer listenCancel()
 	for {
 		select {
-		case <-listenCtx.Done():
-			logger.Infof("Stopping worker %d", workerID)
+		case <-ctx.Done():
+			logger.Infof("Stopping receiver %d", receiverID)
 			return
 		default:
 			if s.health.isShuttingDown() {
-				logger.Infof("Stopping worker %d", workerID)
+				logger.Infof("Stopping receiver %d", receiverID)
 				return
 			}
-			if !s.health.isGood() {
+			if !s.health.isGood() || s.health.isPaused() {
+				time.Sleep(consumptionRecheckInterval)
 				continue
 			}
-			notifications := s.conceptUpdatesSqs.ListenAndServeQueue(listenCtx)
-			nslen := len(notifications)
-			if nslen <= 0 {
+			slots := acquireInFlightSlots(ctx, inFlight, sqs.MaxNumberOfMessages)
+			if slots == 0 {
 				continue
 			}
-			logger.Infof("Worker %d processing notifications", workerID)
-			var wg sync.WaitGroup
-			wg.Add(nslen)
+			notifications := s.conceptUpdatesSqs.ListenAndServeQueue(listenCtx, slots)
+			releaseInFlightSlots(inFlight, slots-len(notifications))
+			if len(notifications) == 0 {
+				continue
+			}
+			if s.health.isShuttingDown() {
+				// messages received while the service was stopping are handed straight back to the queue
+				s.releaseConceptUpdates(notifications)
+				releaseInFlightSlots(inFlight, len(notifications))
+				logger.Infof("Stopping receiver %d", receiverID)
+				return
+			}
 			for _, n := range notifications {
-				go func(ctx context.Context, reqWG *sync.WaitGroup, update sqs.ConceptUpdate) {
-					defer reqWG.Done()
-					err := s.processConceptUpdate(ctx, update)
-					if err != nil {
-						logger.WithError(err).WithUUID(update.UUID).Error("Error processing message.")
-					}
-
-				}(listenCtx, &wg, n)
+				updates <- n
 			}
-			wg.Wait()
 		}
 	}
 }
 
+func (s *AggregateService) processConceptUpdates(updates <-chan sqs.ConceptUpdate, inFlight <-chan struct{}) {
+	for update := range updates {
+		if s.health.isShuttingDown() {
+			// updates which have not been started are handed back rather than holding up the shutdown
+			s.releaseConceptUpdates([]sqs.ConceptUpdate{update})
+		} else if err := s.processConceptUpdate(context.Background(), update); err != nil {
+			logger.WithError(err).WithUUID(update.UUID).Error("Error processing message.")
+		}
+		<-inFlight
+	}
+}
+
+// acquireInFlightSlots waits for at least one free in-flight slot and then takes as many more as are free, up to max.
+// It returns the number of slots taken, which is zero only if ctx is done while waiting.
+func acquireInFlightSlots(ctx context.Context, inFlight chan<- struct{}, max int) int {
+	select {
+	case inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return 0
+	}
+	slots := 1
+	for slots < max {
+		select {
+		case inFlight <- struct{}{}:
+			slots++
+		default:
+			return slots
+		}
+	}
+	return slots
+}
+
+func releaseInFlightSlots(inFlight <-chan struct{}, slots int) {
+	for i := 0; i < slots; i++ {
+		<-inFlight
+	}
+}
+
 func (s *AggregateService) processConceptUpdate(ctx context.Context, n sqs.ConceptUpdate) error {
 	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, s.processTimeout)
 	defer timeoutCancel()
 
 	errCh := make(chan error)
 	go func(ch chan<- error) {
-		internalErr := s.ProcessMessage(timeoutCtx, n.UUID, n.Bookmark)
+		stopHeartbeat := s.startVisibilityHeartbeat(timeoutCtx, n)
+		transactionID, internalErr := s.processMessage(timeoutCtx, n.UUID, n.Bookmark, false)
+		// stop extending the visibility before the message is deleted or its retry is scheduled
+		stopHeartbeat()
 		if internalErr != nil {
+			// use the parent context so that a message which timed out can still be dealt with
+			s.handleFailedConceptUpdate(ctx, n, transactionID, internalErr)
 			ch <- internalErr
 			return
 		}
@@ -220,42 +381,207 @@ func (s *AggregateService) processConceptUpdate(ctx context.Context, n sqs.Conce
 	return err
 }
 
+// startVisibilityHeartbeat keeps the message hidden from other workers while it is being processed,
+// by extending its visibility timeout at regular intervals until the returned function is called or ctx is done.
+func (s *AggregateService) startVisibilityHeartbeat(ctx context.Context, n sqs.ConceptUpdate) func() {
+	if s.visibilityTimeout <= 0 || n.ReceiptHandle == nil {
+		return func() {}
+	}
+	heartbeatCtx, heartbeatCancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// extend well before the timeout expires, so a slow SQS call does not let the message become visible
+		ticker := time.NewTicker(s.visibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatCtx.Done():
+				return
+			case <-ticker.C:
+				err := s.conceptUpdatesSqs.ChangeMessageVisibility(heartbeatCtx, n.ReceiptHandle, int(s.visibilityTimeout.Seconds()))
+				if err != nil && heartbeatCtx.Err() == nil {
+					logger.WithError(err).WithUUID(n.UUID).Warn("Error extending visibility of message")
+				}
+			}
+		}
+	}()
+	return func() {
+		heartbeatCancel()
+		wg.Wait()
+	}
+}
+
+// releaseConceptUpdates makes the messages visible again straight away, so another pod can pick them up.
+func (s *AggregateService) releaseConceptUpdates(notifications []sqs.ConceptUpdate) {
+	for _, n := range notifications {
+		if err := s.conceptUpdatesSqs.ChangeMessageVisibility(context.Background(), n.ReceiptHandle, 0); err != nil {
+			logger.WithError(err).WithUUID(n.UUID).Error("Error releasing message back to the queue")
+		}
+	}
+}
+
+// handleFailedConceptUpdate decides what happens to the message of an update which could not be processed:
+// permanent and validation failures are quarantined straight away, transient failures are retried with an increasing backoff
+// until they are dead-lettered, and an unavailable downstream pauses consumption altogether.
+func (s *AggregateService) handleFailedConceptUpdate(ctx context.Context, n sqs.ConceptUpdate, transactionID string, cause error) {
+	kind := failure.KindOf(cause)
+	switch kind {
+	case failure.Permanent, failure.Validation:
+		if err := s.conceptUpdatesSqs.QuarantineMessage(ctx, n, transactionID, cause.Error()); err != nil {
+			logger.WithError(err).WithTransactionID(transactionID).WithUUID(n.UUID).Error("Error quarantining message")
+			return
+		}
+		logger.WithError(cause).
+			WithTransactionID(transactionID).
+			WithUUID(n.UUID).
+			WithField("alert_tag", "AggregateConceptTransformerQuarantine").
+			WithField("bookmark", n.Bookmark).
+			WithField("failure_kind", kind.String()).
+			Error("Message quarantined as it can never be processed")
+	case failure.Unavailable:
+		logger.WithError(cause).
+			WithTransactionID(transactionID).
+			WithUUID(n.UUID).
+			WithField("alert_tag", "AggregateConceptTransformerDownstreamUnavailable").
+			Warnf("Downstream service is unavailable, pausing consumption for %s", s.unavailablePause)
+		s.health.pauseUntil(time.Now().Add(s.unavailablePause))
+		// a message which keeps failing on the same service still goes to the dead-letter queue in the end
+		if s.deadLetterConceptUpdate(ctx, n, transactionID, cause) {
+			return
+		}
+		s.retryConceptUpdateAfter(ctx, n, transactionID, s.unavailablePause)
+	default:
+		if s.deadLetterConceptUpdate(ctx, n, transactionID, cause) {
+			return
+		}
+		s.retryConceptUpdateAfter(ctx, n, transactionID, s.backoff(n.ReceiveCount))
+	}
+}
+
+// deadLetterConceptUpdate reports whether the message was moved to the dead-letter queue.
+func (s *AggregateService) deadLetterConceptUpdate(ctx context.Context, n sqs.ConceptUpdate, transactionID string, cause error) bool {
+	moved, err := s.conceptUpdatesSqs.DeadLetterMessage(ctx, n, transactionID, cause.Error())
+	if err != nil {
+		logger.WithError(err).WithTransactionID(transactionID).WithUUID(n.UUID).Error("Error moving message to the dead-letter queue")
+		return moved
+	}
+	if moved {
+		logger.WithError(cause).
+			WithTransactionID(transactionID).
+			WithUUID(n.UUID).
+			WithField("alert_tag", "AggregateConceptTransformerDeadLetter").
+			WithField("bookmark", n.Bookmark).
+			Errorf("Message moved to the dead-letter queue after %d receives", n.ReceiveCount)
+	}
+	return moved
+}
+
+// retryConceptUpdateAfter keeps the message hidden on the queue for the given delay, after which it will be received again.
+func (s *AggregateService) retryConceptUpdateAfter(ctx context.Context, n sqs.ConceptUpdate, transactionID string, delay time.Duration) {
+	if err := s.conceptUpdatesSqs.ChangeMessageVisibility(ctx, n.ReceiptHandle, int(delay.Seconds())); err != nil {
+		logger.WithError(err).WithTransactionID(transactionID).WithUUID(n.UUID).Error("Error delaying retry of message")
+	}
+}
+
+// backoff doubles the retry delay with every receive of the message, up to maxRetryBackoff.
+func (s *AggregateService) backoff(receiveCount int) time.Duration {
+	delay := s.retryBackoff
+	for i := 1; i < receiveCount && delay < s.maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.maxRetryBackoff {
+		return s.maxRetryBackoff
+	}
+	return delay
+}
+
 func (s *AggregateService) ProcessMessage(ctx context.Context, UUID string, bookmark string) error {
+	_, err := s.processMessage(ctx, UUID, bookmark, false)
+	return err
+}
+
+// ForceProcessMessage aggregates and writes the concept even when it is unchanged since it was last written.
+func (s *AggregateService) ForceProcessMessage(ctx context.Context, UUID string) error {
+	_, err := s.processMessage(ctx, UUID, "", true)
+	return err
+}
+
+// processMessage aggregates and writes the concept, returning the transaction ID of the update alongside any error.
+// Updates are serialised per source UUID, and then per canonical concept, so concurrent updates never race their writes.
+// Unless forced, the concept is not written when its content hash is the one of the last concept written for its PrefUUID.
+func (s *AggregateService) processMessage(ctx context.Context, UUID string, bookmark string, force bool) (string, error) {
 	if s.readOnly {
-		return errors.New("aggregate service is in read-only mode")
+		return "", failure.Wrap(failure.Permanent, errors.New("aggregate service is in read-only mode"))
 	}
+	return s.serialiser.Do(ctx, "source:"+UUID, update{Bookmark: bookmark, Force: force}, func(ctx context.Context, _ bool, u update) (string, error) {
+		return s.aggregateAndWrite(ctx, UUID, u)
+	})
+}
+
+func (s *AggregateService) aggregateAndWrite(ctx context.Context, UUID string, u update) (string, error) {
 	// Get the concorded concept
-	concordedConcept, transactionID, err := s.GetConcordedConcept(ctx, UUID, bookmark)
+	concordedConcept, transactionID, err := s.GetConcordedConcept(ctx, UUID, u.Bookmark)
 	if err != nil {
-		return err
+		return transactionID, err
 	}
 
+	return s.serialiser.Do(ctx, "concept:"+concordedConcept.PrefUUID, u, func(ctx context.Context, waited bool, u update) (string, error) {
+		if waited {
+			// another update of the same concept was written in the meantime, so aggregate again to include its changes
+			concordedConcept, transactionID, err = s.GetConcordedConcept(ctx, UUID, u.Bookmark)
+			if err != nil {
+				return transactionID, err
+			}
+		}
+		return s.writeConcordedConcept(ctx, UUID, concordedConcept, transactionID, u.Force)
+	})
+}
+
+// writeConcordedConcept sends the concept to the writers, purges it from the cache and publishes the resulting events.
+func (s *AggregateService) writeConcordedConcept(ctx context.Context, UUID string, concordedConcept ontology.CanonicalConcept, transactionID string, force bool) (string, error) {
 	// Extract only the real UUID when publication is present, safe as the uuid is alway at least 36 characters
 	UUID = UUID[len(UUID)-lengthOfUUID:]
 	if concordedConcept.PrefUUID != UUID {
 		logger.WithTransactionID(transactionID).WithUUID(UUID).Infof("Requested concept %s is source node for canonical concept %s", UUID, concordedConcept.PrefUUID)
 	}
 
+	if violations := s.canonicalValidators.Validate(concordedConcept); len(violations) > 0 {
+		err := &CanonicalViolationsError{UUID: concordedConcept.PrefUUID, Type: concordedConcept.Type, Violations: violations}
+		logger.WithError(err).WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).
+			WithField("alert_tag", "AggregateConceptTransformerInvalidCanonicalConcept").
+			Error("Canonical concept breaks the invariants of its type, not writing it")
+		return transactionID, failure.Wrap(failure.Validation, err)
+	}
+
+	hash, unchanged := s.checkContentHash(ctx, concordedConcept, transactionID)
+	if unchanged && !force {
+		logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Info("concept has the same content hash as when it was last written, skipping!")
+		return transactionID, nil
+	}
+
 	// Write to Neo4j
 	logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Debug("Sending concept to Neo4j")
 	conceptChanges, err := sendToWriter(ctx, s.httpClient, s.neoWriterAddress, resolveConceptType(concordedConcept.Type), concordedConcept.PrefUUID, transactionID, concordedConcept)
 	if err != nil {
-		return err
+		return transactionID, err
 	}
 	rawJson, err := json.Marshal(conceptChanges)
 	if err != nil {
 		logger.WithError(err).WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Errorf("failed to marshall concept changes record: %v", conceptChanges)
-		return err
+		return transactionID, err
 	}
 	var updateRecord sns.ConceptChanges
 	if err = json.Unmarshal(rawJson, &updateRecord); err != nil {
 		logger.WithError(err).WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Errorf("failed to unmarshall raw json into update record: %v", rawJson)
-		return err
+		return transactionID, err
 	}
 
 	if len(updateRecord.ChangedRecords) < 1 {
 		logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Info("concept was unchanged since last update, skipping!")
-		return nil
+		s.storeContentHash(ctx, concordedConcept.PrefUUID, hash, transactionID)
+		return transactionID, nil
 	}
 	logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Debug("concept successfully updated in neo4j")
 
@@ -265,97 +591,81 @@ func (s *AggregateService) ProcessMessage(ctx context.Context, UUID string, book
 		logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Errorf("Concept couldn't be purged from Varnish cache")
 	}
 
-	//optionally purge other affected concepts
-	if concordedConcept.Type == "FinancialInstrument" {
-		if err = sendToPurger(ctx, s.httpClient, s.varnishPurgerAddress, []string{concordedConcept.IssuedBy}, "Organisation", s.typesToPurgeFromPublicEndpoints, transactionID); err != nil {
-			logger.WithTransactionID(transactionID).WithUUID(concordedConcept.IssuedBy).Errorf("Concept couldn't be purged from Varnish cache")
-		}
-	}
-
-	if concordedConcept.Type == "Membership" {
-		personUUID, err := getPersonUUIDFromConcept(concordedConcept)
-		if err != nil {
-			logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).WithError(err).Errorf("Concept couldn't be purged from Varnish cache")
-		} else {
-			err = sendToPurger(ctx, s.httpClient, s.varnishPurgerAddress, []string{personUUID}, "Person", s.typesToPurgeFromPublicEndpoints, transactionID)
-			if err != nil {
-				logger.WithTransactionID(transactionID).WithUUID(personUUID).WithError(err).Errorf("Concept couldn't be purged from Varnish cache")
-			}
-		}
-	}
+	// purge the related concepts given by the purge rules for the type of the concept
+	s.purgeRelatedConcepts(ctx, concordedConcept, transactionID)
 
 	// Write to Elasticsearch
-	if isTypeAllowedInElastic(concordedConcept) {
+	if s.allowedInElasticsearch(concordedConcept) {
 		logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Debug("Writing concept to elastic search")
 		if _, err = sendToWriter(ctx, s.httpClient, s.elasticsearchWriterAddress, resolveConceptType(concordedConcept.Type), concordedConcept.PrefUUID, transactionID, concordedConcept); err != nil {
-			return err
+			return transactionID, err
 		}
 	}
 
 	if err = s.eventsSns.PublishEvents(ctx, updateRecord.ChangedRecords); err != nil {
 		logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Errorf("unable to send events: %v to Event Queue", updateRecord.ChangedRecords)
-		return err
+		return transactionID, err
 	}
 
 	//Send notification to stream
 	rawIDList, err := json.Marshal(conceptChanges.UpdatedIds)
 	if err != nil {
 		logger.WithError(err).WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Errorf("failed to marshall concept changes record: %v", conceptChanges.UpdatedIds)
-		return err
+		return transactionID, err
 	}
 	logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Debugf("sending notification of updated concepts to kinesis conceptsQueue: %v", conceptChanges)
 	if err = s.kinesis.AddRecordToStream(ctx, rawIDList, concordedConcept.Type); err != nil {
 		logger.WithError(err).WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Errorf("Failed to update stream with notification record %v", conceptChanges)
-		return err
+		return transactionID, err
 	}
+	s.storeContentHash(ctx, concordedConcept.PrefUUID, hash, transactionID)
 	logger.WithTransactionID(transactionID).WithUUID(concordedConcept.PrefUUID).Infof("Finished processing update of %s", UUID)
 
-	return nil
+	return transactionID, nil
 }
 
-func bucketConcordances(concordanceRecords []concordances.ConcordanceRecord) (map[string][]concordances.ConcordanceRecord, string, error) {
-	if concordanceRecords == nil || len(concordanceRecords) == 0 {
-		err := fmt.Errorf("no concordances provided")
-		logger.WithError(err).Error("Error grouping concordance records")
-		return nil, "", err
+// checkContentHash returns the content hash of the concept and whether it is the one of the last concept written for its PrefUUID
+// with the same configuration.
+// The concept is treated as changed when there is no content hash store or it cannot be read, so that it is written.
+func (s *AggregateService) checkContentHash(ctx context.Context, concept ontology.CanonicalConcept, transactionID string) (string, bool) {
+	if s.contentHashes == nil {
+		return "", false
 	}
-
-	bucketedConcordances := map[string][]concordances.ConcordanceRecord{}
-	for _, v := range concordanceRecords {
-		bucketedConcordances[v.Authority] = append(bucketedConcordances[v.Authority], v)
+	hash, err := contentHash(concept, s.writeConfig())
+	if err != nil {
+		logger.WithError(err).WithTransactionID(transactionID).WithUUID(concept.PrefUUID).Warn("Error computing the content hash of the concept")
+		return "", false
 	}
-
-	var primaryAuthority string
-	var err error
-	slRecords, slFound := bucketedConcordances[ontology.SmartlogicAuthority]
-	if slFound {
-		if len(slRecords) == 1 {
-			primaryAuthority = ontology.SmartlogicAuthority
-		} else {
-			err = fmt.Errorf("more than 1 primary authority")
-		}
+	previous, found, err := s.contentHashes.Get(ctx, concept.PrefUUID)
+	if err != nil {
+		logger.WithError(err).WithTransactionID(transactionID).WithUUID(concept.PrefUUID).Warn("Error reading the content hash of the last concept written")
+		return hash, false
 	}
-	mlRecords, mlFound := bucketedConcordances[ontology.ManagedLocationAuthority]
-	if mlFound {
-		if len(mlRecords) == 1 {
-			if primaryAuthority == "" {
-				primaryAuthority = ontology.ManagedLocationAuthority
-			}
-		} else {
-			err = fmt.Errorf("more than 1 ManagedLocation primary authority")
-		}
+	return hash, found && previous == hash
+}
+
+// storeContentHash remembers the content hash of a concept once it has been written.
+// A hash which cannot be stored only means the next update of the concept is written even if unchanged.
+func (s *AggregateService) storeContentHash(ctx context.Context, UUID string, hash string, transactionID string) {
+	if s.contentHashes == nil || hash == "" {
+		return
 	}
-	if err != nil {
-		logger.WithError(err).
-			WithField("alert_tag", "AggregateConceptTransformerMultiplePrimaryAuthorities").
-			WithField("primary_authorities", fmt.Sprintf("Smartlogic=%v, ManagedLocation=%v", slRecords, mlRecords)).
-			Error("Error grouping concordance records")
-		return nil, "", err
+	if err := s.contentHashes.Set(ctx, UUID, hash); err != nil {
+		logger.WithError(err).WithTransactionID(transactionID).WithUUID(UUID).Warn("Error storing the content hash of the concept")
 	}
-	return bucketedConcordances, primaryAuthority, nil
 }
 
 func (s *AggregateService) GetConcordedConcept(ctx context.Context, UUID string, bookmark string) (ontology.CanonicalConcept, string, error) {
+	return s.concordedConcept(ctx, UUID, bookmark, time.Time{})
+}
+
+// GetConcordedConceptAsOf aggregates a concept from the versions of its sources which were current at the given time.
+// The concordances are always the current ones, as the concordance store does not keep their history.
+func (s *AggregateService) GetConcordedConceptAsOf(ctx context.Context, UUID string, asOf time.Time) (ontology.CanonicalConcept, string, error) {
+	return s.concordedConcept(ctx, UUID, "", asOf)
+}
+
+func (s *AggregateService) concordedConcept(ctx context.Context, UUID string, bookmark string, asOf time.Time) (ontology.CanonicalConcept, string, error) {
 	type concordedData struct {
 		Concept       ontology.CanonicalConcept
 		TransactionID string
@@ -364,7 +674,7 @@ func (s *AggregateService) GetConcordedConcept(ctx context.Context, UUID string,
 	ch := make(chan concordedData)
 
 	go func() {
-		concept, tranID, err := s.getConcordedConcept(ctx, UUID, bookmark)
+		concept, tranID, err := s.getConcordedConcept(ctx, UUID, bookmark, asOf)
 		ch <- concordedData{Concept: concept, TransactionID: tranID, Err: err}
 	}()
 	select {
@@ -375,75 +685,104 @@ func (s *AggregateService) GetConcordedConcept(ctx context.Context, UUID string,
 	}
 }
 
-// nolint: gocognit // TODO: fix 'cognitive complexity 21 of func `(*AggregateService).getConcordedConcept` is high (> 20) (gocognit)'
-func (s *AggregateService) getConcordedConcept(ctx context.Context, UUID string, bookmark string) (ontology.CanonicalConcept, string, error) {
+func (s *AggregateService) getConcordedConcept(ctx context.Context, UUID string, bookmark string, asOf time.Time) (ontology.CanonicalConcept, string, error) {
+	agg, err := s.gatherSources(ctx, UUID, bookmark, asOf)
+	if err != nil || agg == nil {
+		return ontology.CanonicalConcept{}, "", err
+	}
+	concordedConcept := aggregate.CreateCanonicalConcept(agg.primary, agg.sources)
+	return concordedConcept, agg.transactionID, nil
+}
+
+// sourceAggregation holds the source concepts a canonical concept is aggregated from.
+// The sources are in the order they are merged in, and the primary concept is merged last.
+type sourceAggregation struct {
+	records       []concordances.ConcordanceRecord
+	primaryRecord concordances.ConcordanceRecord
+	primaryReason string
+	primary       ontology.SourceConcept
+	sources       []ontology.SourceConcept
+	transactionID string
+}
+
+// gatherSources reads the concordances of the concept and its source concepts, and picks the primary concept.
+// It returns nil when there are no sources at all.
+// nolint: gocognit // TODO: fix 'cognitive complexity 21 of func `(*AggregateService).gatherSources` is high (> 20) (gocognit)'
+func (s *AggregateService) gatherSources(ctx context.Context, UUID string, bookmark string, asOf time.Time) (*sourceAggregation, error) {
 	var transactionID string
 	var err error
 	sourceConcepts := []ontology.SourceConcept{}
 
 	cleanedUUID, publication, err := extractIdentifiersFromKey(UUID)
 	if err != nil {
-		return ontology.CanonicalConcept{}, "", err
+		return nil, failure.Wrap(failure.Validation, err)
 	}
 	concordedRecords, err := s.concordances.GetConcordance(ctx, cleanedUUID, bookmark)
 	if err != nil {
-		return ontology.CanonicalConcept{}, "", err
+		return nil, err
 	}
 	logger.WithField("UUID", cleanedUUID).Debugf("Returned concordance record: %v", concordedRecords)
 
-	bucketedConcordances, primaryAuthority, err := bucketConcordances(concordedRecords)
+	var fetched []fetchedConcept
+	if len(concordedRecords) == 1 && concordedRecords[0].Solo {
+		if fetched, err = s.fetchSoloConcept(ctx, publication, concordedRecords, asOf); err != nil {
+			return nil, err
+		}
+	}
+
+	primaryRecord, sourceRecords, primaryReason, err := bucketConcordances(concordedRecords, s.primaryAuthorityRules)
+	var conflict *PrimaryAuthorityConflictError
+	if errors.As(err, &conflict) {
+		s.conflicts.record(cleanedUUID, conflict)
+	}
 	if err != nil {
-		return ontology.CanonicalConcept{}, "", err
+		return nil, err
 	}
+	s.conflicts.resolve(cleanedUUID)
+	// sort the sources to always aggregate them in the same order, whatever order the concordances were returned in
+	s.authorityPrecedence.sortConcordances(sourceRecords)
 
-	// Get all concepts from S3
-	for authority, concordanceRecords := range bucketedConcordances {
-		if authority == primaryAuthority {
-			continue
+	records := sourceRecords
+	if primaryRecord.UUID != "" {
+		records = append(records, primaryRecord)
+	}
+	if fetched == nil {
+		// Get all concepts from S3
+		if fetched, err = s.fetchConcepts(ctx, publication, records, asOf); err != nil {
+			return nil, err
 		}
-		for _, conc := range concordanceRecords {
-			var found bool
-			var sourceConcept ontology.SourceConcept
-			if publication != "" {
-				found, sourceConcept, transactionID, err = s.externalNormalisedStore.GetConceptAndTransactionID(ctx, publication, conc.UUID)
-			} else {
-				found, sourceConcept, transactionID, err = s.nStore.GetConceptAndTransactionID(ctx, "", conc.UUID)
-			}
-
-			if err != nil {
-				return ontology.CanonicalConcept{}, "", err
-			}
-
-			if !found {
-				//we should let the concorded concept to be written as a "Thing"
-				logger.WithField("UUID", cleanedUUID).Warn(fmt.Sprintf("Source concept %s not found in S3", conc))
-				sourceConcept.Authority = authority
-				sourceConcept.AuthorityValue = conc.AuthorityValue
-				sourceConcept.UUID = conc.UUID
-				sourceConcept.Type = "Thing"
-			}
+	}
+	if err = s.validateSources(cleanedUUID, records, fetched, primaryRecord.UUID != ""); err != nil {
+		return nil, err
+	}
+	if len(fetched) > 0 {
+		// the transaction ID is the primary concept's, or the one of the source with the highest precedence if there is none
+		transactionID = fetched[len(fetched)-1].transactionID
+	}
 
-			sourceConcepts = append(sourceConcepts, sourceConcept)
+	for i, conc := range sourceRecords {
+		sourceConcept := fetched[i].concept
+		if !fetched[i].found {
+			//we should let the concorded concept to be written as a "Thing"
+			logger.WithField("UUID", cleanedUUID).Warn(fmt.Sprintf("Source concept %s not found in S3", conc.UUID))
+			sourceConcept.Authority = conc.Authority
+			sourceConcept.AuthorityValue = conc.AuthorityValue
+			sourceConcept.UUID = conc.UUID
+			sourceConcept.Type = "Thing"
 		}
+
+		sourceConcepts = append(sourceConcepts, sourceConcept)
 	}
 
 	var primaryConcept ontology.SourceConcept
-	var foundPrimary bool
-	if primaryAuthority != "" {
-		canonicalConcept := bucketedConcordances[primaryAuthority][0]
-		if publication != "" {
-			foundPrimary, primaryConcept, transactionID, err = s.externalNormalisedStore.GetConceptAndTransactionID(ctx, publication, canonicalConcept.UUID)
-		} else {
-			foundPrimary, primaryConcept, transactionID, err = s.nStore.GetConceptAndTransactionID(ctx, "", canonicalConcept.UUID)
-		}
-
-		if err != nil {
-			return ontology.CanonicalConcept{}, "", err
-		} else if !foundPrimary {
-			err = fmt.Errorf("canonical concept %s not found in S3", canonicalConcept.UUID)
+	if primaryRecord.UUID != "" {
+		primary := fetched[len(fetched)-1]
+		if !primary.found {
+			err = fmt.Errorf("canonical concept %s not found in S3", primaryRecord.UUID)
 			logger.WithField("UUID", cleanedUUID).Error(err.Error())
-			return ontology.CanonicalConcept{}, "", err
+			return nil, err
 		}
+		primaryConcept = primary.concept
 	}
 
 	// transform concepts to the new format
@@ -454,15 +793,153 @@ func (s *AggregateService) getConcordedConcept(ctx context.Context, UUID string,
 			// sanity check. concordances gathering should return 404 if there are no sources.
 			// we don't return an error in order to keep the same behavior as in v1.23 of the service.
 			logger.WithTransactionID(transactionID).WithUUID(UUID).Error("no sources found")
-			return ontology.CanonicalConcept{}, "", nil
+			return nil, nil
 		}
-		// set the primary concept to the last source concept to keep the behaviour the same as in v1.23
+		primaryReason = fmt.Sprintf("%s, so the source with the highest authority precedence is used", primaryReason)
+		// set the primary concept to the last source concept, which is the one with the highest authority precedence
 		primaryConcept = sourceConcepts[sourceCount-1]
 		sourceConcepts = sourceConcepts[:sourceCount-1]
 	}
 
-	concordedConcept := aggregate.CreateCanonicalConcept(primaryConcept, sourceConcepts)
-	return concordedConcept, transactionID, nil
+	return &sourceAggregation{
+		records:       concordedRecords,
+		primaryRecord: primaryRecord,
+		primaryReason: primaryReason,
+		primary:       primaryConcept,
+		sources:       sourceConcepts,
+		transactionID: transactionID,
+	}, nil
+}
+
+// fetchSoloConcept reads the concept of a solo concordance record from S3 and sets the authority of the record to the concept's,
+// so that it is only used as primary when it really comes from a primary authority.
+func (s *AggregateService) fetchSoloConcept(ctx context.Context, publication string, records []concordances.ConcordanceRecord, asOf time.Time) ([]fetchedConcept, error) {
+	fetched, err := s.fetchConcepts(ctx, publication, records, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if !fetched[0].found {
+		err = fmt.Errorf("canonical concept %s not found in S3", records[0].UUID)
+		logger.WithField("UUID", records[0].UUID).Error(err.Error())
+		return nil, err
+	}
+	records[0].Authority = fetched[0].concept.Authority
+	return fetched, nil
+}
+
+// Conflicts returns the concordance conflicts which have stopped concepts from being aggregated, the most recently seen first.
+func (s *AggregateService) Conflicts() []ConcordanceConflict {
+	return s.conflicts.list()
+}
+
+// fetchedConcept is a source concept read from S3. document is the JSON the concept was decoded from, as it is stored,
+// and is nil when the store only returns decoded concepts. decodeErr is set when the document could not be decoded.
+type fetchedConcept struct {
+	found         bool
+	concept       ontology.SourceConcept
+	document      []byte
+	decodeErr     error
+	transactionID string
+}
+
+// fetchConcepts reads the concepts of the given concordance records from S3, fetching at most sourceFetchConcurrency of them at a time.
+// The concepts are read as they were at asOf, unless it is zero.
+// The results are in the same order as the records. The first error cancels the fetches which have not finished yet.
+func (s *AggregateService) fetchConcepts(ctx context.Context, publication string, records []concordances.ConcordanceRecord, asOf time.Time) ([]fetchedConcept, error) {
+	fetch, err := s.conceptFetcher(publication, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]fetchedConcept, len(records))
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, max(s.sourceFetchConcurrency, 1))
+	for i, record := range records {
+		select {
+		case limit <- struct{}{}:
+		case <-fetchCtx.Done():
+		}
+		if fetchCtx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, UUID string) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			result, err := fetch(fetchCtx, UUID)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+				return
+			}
+			results[i] = result
+		}(i, record.UUID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// conceptFetcher returns the function reading the concepts of the publication as they were at asOf, unless it is zero.
+// The JSON documents of the concepts are read and then decoded when the store can return them.
+func (s *AggregateService) conceptFetcher(publication string, asOf time.Time) (func(ctx context.Context, UUID string) (fetchedConcept, error), error) {
+	var store normalisedClient = s.nStore
+	if publication != "" {
+		store, publication = s.externalNormalisedStore.route(publication)
+	}
+
+	getConcept := store.GetConceptAndTransactionID
+	var getDocument func(ctx context.Context, publication string, UUID string) (bool, []byte, string, error)
+	if documents, ok := store.(documentClient); ok {
+		getDocument = documents.GetConceptDocument
+	}
+	if !asOf.IsZero() {
+		versioned, ok := store.(versionedClient)
+		if !ok {
+			return nil, failure.Wrap(failure.Permanent, ErrVersionsNotSupported)
+		}
+		getConcept = func(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+			return versioned.GetConceptAndTransactionIDAsOf(ctx, publication, UUID, asOf)
+		}
+		getDocument = nil
+		if documents, ok := store.(versionedDocumentClient); ok {
+			getDocument = func(ctx context.Context, publication string, UUID string) (bool, []byte, string, error) {
+				return documents.GetConceptDocumentAsOf(ctx, publication, UUID, asOf)
+			}
+		}
+	}
+
+	if getDocument == nil {
+		return func(ctx context.Context, UUID string) (fetchedConcept, error) {
+			found, concept, transactionID, err := getConcept(ctx, publication, UUID)
+			return fetchedConcept{found: found, concept: concept, transactionID: transactionID}, err
+		}, nil
+	}
+	return func(ctx context.Context, UUID string) (fetchedConcept, error) {
+		found, document, transactionID, err := getDocument(ctx, publication, UUID)
+		if err != nil || !found {
+			return fetchedConcept{found: found, transactionID: transactionID}, err
+		}
+		// the document is validated before the concept is used, so a document which cannot be decoded is only reported then
+		result := fetchedConcept{found: true, document: document, transactionID: transactionID}
+		result.decodeErr = json.Unmarshal(document, &result.concept)
+		return result, nil
+	}, nil
 }
 
 func (s *AggregateService) Healthchecks() []fthealth.Check {
@@ -471,6 +948,7 @@ func (s *AggregateService) Healthchecks() []fthealth.Check {
 		s.externalNormalisedStore.Healthcheck(),
 		s.concordances.Healthcheck(),
 	}
+	checks = append(checks, s.externalNormalisedStore.routeHealthchecks()...)
 	if !s.readOnly {
 		checks = append(checks, s.conceptUpdatesSqs.Healthcheck())
 		checks = append(checks, s.RWElasticsearchHealthCheck())
@@ -511,18 +989,9 @@ func sendToPurger(ctx context.Context, client httpClient, baseURL string, concep
 	}
 
 	queryParams := req.URL.Query()
-	for _, cUUID := range conceptUUIDs {
-		queryParams.Add("target", thingsAPIEndpoint+"/"+cUUID)
-		queryParams.Add("target", conceptsAPIEnpoint+"/"+cUUID)
+	for _, target := range purgeTargets(conceptUUIDs, conceptType, conceptTypesWithPublicEndpoints) {
+		queryParams.Add("target", target)
 	}
-
-	if contains(conceptType, conceptTypesWithPublicEndpoints) {
-		urlParam := resolveConceptType(conceptType)
-		for _, cUUID := range conceptUUIDs {
-			queryParams.Add("target", "/"+urlParam+"/"+cUUID)
-		}
-	}
-
 	req.URL.RawQuery = queryParams.Encode()
 
 	resp, err := client.Do(req)
@@ -540,6 +1009,22 @@ func sendToPurger(ctx context.Context, client httpClient, baseURL string, concep
 	return err
 }
 
+// purgeTargets returns the paths purged from the cache for the given concepts.
+func purgeTargets(conceptUUIDs []string, conceptType string, conceptTypesWithPublicEndpoints []string) []string {
+	var targets []string
+	for _, cUUID := range conceptUUIDs {
+		targets = append(targets, thingsAPIEndpoint+"/"+cUUID, conceptsAPIEnpoint+"/"+cUUID)
+	}
+
+	if contains(conceptType, conceptTypesWithPublicEndpoints) {
+		urlParam := resolveConceptType(conceptType)
+		for _, cUUID := range conceptUUIDs {
+			targets = append(targets, "/"+urlParam+"/"+cUUID)
+		}
+	}
+	return targets
+}
+
 func contains(element string, types []string) bool {
 	for _, t := range types {
 		if element == t {
@@ -553,7 +1038,7 @@ func sendToWriter(ctx context.Context, client httpClient, baseURL, urlParam, con
 	updatedConcepts := sns.ConceptChanges{}
 	body, err := json.Marshal(concept)
 	if err != nil {
-		return updatedConcepts, err
+		return updatedConcepts, failure.Wrap(failure.Validation, err)
 	}
 
 	request, reqURL, err := createWriteRequest(ctx, baseURL, urlParam, strings.NewReader(string(body)), conceptUUID)
@@ -567,7 +1052,7 @@ func sendToWriter(ctx context.Context, client httpClient, baseURL, urlParam, con
 	resp, err := client.Do(request)
 	if err != nil {
 		logger.WithError(err).WithTransactionID(tid).WithUUID(conceptUUID).Errorf("Request to %s returned error", reqURL)
-		return updatedConcepts, err
+		return updatedConcepts, failure.FromTransport(err)
 	}
 
 	defer resp.Body.Close()
@@ -587,7 +1072,7 @@ func sendToWriter(ctx context.Context, client httpClient, baseURL, urlParam, con
 	if resp.StatusCode != 200 && resp.StatusCode != 304 {
 		err := errors.New("Request to " + reqURL + " returned status: " + strconv.Itoa(resp.StatusCode) + "; skipping " + conceptUUID)
 		logger.WithTransactionID(tid).WithUUID(conceptUUID).Errorf("Request to %s returned status: %d", reqURL, resp.StatusCode)
-		return updatedConcepts, err
+		return updatedConcepts, failure.FromStatus(resp.StatusCode, err)
 	}
 
 	return updatedConcepts, nil
@@ -700,36 +1185,20 @@ func (s *AggregateService) RWElasticsearchHealthCheck() fthealth.Check {
 	}
 }
 
-func isTypeAllowedInElastic(concordedConcept ontology.CanonicalConcept) bool {
-	switch concordedConcept.Type {
-	case "FinancialInstrument": //, "MembershipRole", "BoardRole":
-		return false
-	case "MembershipRole":
-		return false
-	case "BoardRole":
-		return false
-	case "Membership":
-		for _, sr := range concordedConcept.SourceRepresentations {
-			//Allow smartlogic curated memberships through to elasticsearch as we will use them to discover authors
-			if sr.Authority == ontology.SmartlogicAuthority {
-				return true
-			}
-		}
-		return false
-	case "IndustryClassification", "NAICSIndustryClassification", "FTAnIIndustryClassification":
-		return false
+// purgeRelatedConcepts purges the concepts related to the concept by the purge rules. Failing to purge them does not fail the update.
+func (s *AggregateService) purgeRelatedConcepts(ctx context.Context, concept ontology.CanonicalConcept, transactionID string) {
+	purges, err := s.purgeRules.relatedPurges(concept)
+	if err != nil {
+		logger.WithTransactionID(transactionID).WithUUID(concept.PrefUUID).WithError(err).Errorf("Related concepts couldn't be purged from Varnish cache")
+		return
 	}
-
-	return true
-}
-
-func getPersonUUIDFromConcept(concept ontology.CanonicalConcept) (string, error) {
-	const personRelLabel = "HAS_MEMBER"
-	for _, rel := range concept.Relationships {
-		if rel.Label != personRelLabel {
+	for _, purge := range purges {
+		if len(purge.UUIDs) == 0 {
+			logger.WithTransactionID(transactionID).WithUUID(concept.PrefUUID).Errorf("%s has no related %s to purge from Varnish cache", concept.Type, purge.Type)
 			continue
 		}
-		return rel.UUID, nil
+		if err = sendToPurger(ctx, s.httpClient, s.varnishPurgerAddress, purge.UUIDs, purge.Type, s.typesToPurgeFromPublicEndpoints, transactionID); err != nil {
+			logger.WithTransactionID(transactionID).WithUUID(concept.PrefUUID).WithError(err).Errorf("Related %s concepts %v couldn't be purged from Varnish cache", purge.Type, purge.UUIDs)
+		}
 	}
-	return "", errors.New("membership is missing 'HAS_MEMBER' relationship")
 }
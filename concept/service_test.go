@@ -4,17 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
 	"github.com/Financial-Times/cm-graph-ontology/v2/transform"
 
 	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 	"github.com/Financial-Times/aggregate-concept-transformer/sqs"
 )
 
@@ -85,7 +93,7 @@ func TestNewService(t *testing.T) {
 func TestAggregateService_ListenForNotifications(t *testing.T) {
 	svc, _, mockSqsClient, _, _, _, _ := setupTestService(200, payload)
 	mockSqsClient.On("ListenAndServeQueue").Return([]sqs.ConceptUpdate{})
-	go svc.ListenForNotifications(context.Background(), 1)
+	go svc.ListenForNotifications(context.Background(), 1, 1, 10)
 	time.Sleep(2 * time.Second)
 	assert.Equal(t, 0, len(mockSqsClient.Queue()))
 }
@@ -98,7 +106,7 @@ func TestAggregateService_ListenForNotifications_ProcessNoneIfNotHealthy(t *test
 		time.Sleep(100 * time.Nanosecond)
 	}
 	time.Sleep(10 * time.Millisecond) // I hate waiting :(
-	go svc.ListenForNotifications(context.Background(), 1)
+	go svc.ListenForNotifications(context.Background(), 1, 1, 10)
 	time.Sleep(2 * time.Second)
 	mockSqsClient.AssertNotCalled(t, "ListenAndServeQueue")
 	assert.Equal(t, 1, len(mockSqsClient.Queue()))
@@ -110,26 +118,102 @@ func TestAggregateService_ListenForNotifications_ProcessConceptNotInS3(t *testin
 	var receiptHandle = "1"
 	var nonExistingConcept = "99247059-04ec-3abb-8693-a0b8951fdkor"
 	mockSqsClient.conceptsQueue[receiptHandle] = nonExistingConcept
-	go svc.ListenForNotifications(context.Background(), 1)
+	go svc.ListenForNotifications(context.Background(), 1, 1, 10)
 	time.Sleep(500 * time.Microsecond)
 	hasIt, _, _, err := s3mock.GetConceptAndTransactionID(context.Background(), "", nonExistingConcept)
 	assert.Equal(t, hasIt, false)
 	assert.NoError(t, err)
-	err = mockSqsClient.RemoveMessageFromQueue(context.Background(), &receiptHandle)
-	assert.Equal(t, 0, len(mockSqsClient.Queue()))
-	assert.NoError(t, err)
+	// the key is not a valid UUID, so the message is quarantined rather than retried
+	assert.Eventually(t, func() bool {
+		return len(mockSqsClient.Queue()) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Contains(t, mockSqsClient.DeadLetterQueue(), nonExistingConcept)
 }
 
 func TestAggregateService_ListenForNotifications_CannotProcessRemoveMessageNotPresentOnQueue(t *testing.T) {
 	svc, _, mockSqsClient, _, _, _, _ := setupTestService(200, payload)
 	mockSqsClient.On("ListenAndServeQueue").Return([]sqs.ConceptUpdate{})
 	var receiptHandle = "2"
-	go svc.ListenForNotifications(context.Background(), 1)
+	go svc.ListenForNotifications(context.Background(), 1, 1, 10)
 	err := mockSqsClient.RemoveMessageFromQueue(context.Background(), &receiptHandle)
 	assert.Error(t, err)
 	assert.Equal(t, "Receipt handle not present on conceptsQueue", err.Error())
 }
 
+func TestAggregateService_ListenForNotifications_ReleasesMessagesOnShutdown(t *testing.T) {
+	svc, _, mockSqsClient, _, _, _, done := setupTestService(200, payload)
+	mockSqsClient.On("ListenAndServeQueue").Run(func(mock.Arguments) {
+		// the service is asked to stop while it is waiting for messages
+		done <- struct{}{}
+		for !svc.health.isShuttingDown() {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	stopped := make(chan struct{})
+	go func() {
+		svc.ListenForNotifications(context.Background(), 1, 1, 10)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop")
+	}
+
+	queue := mockSqsClient.Queue()
+	assert.Equal(t, 1, len(queue))
+	for receiptHandle := range queue {
+		assert.Equal(t, map[string]int{receiptHandle: 0}, mockSqsClient.Visibility())
+	}
+}
+
+func TestAggregateService_ListenForNotifications_SlowUpdateDoesNotHoldUpOthers(t *testing.T) {
+	svc, s3mock, mockSqsClient, _, _, _, done := setupTestServiceWithTimeout(200, payload, 5*time.Second)
+	mockSqsClient.On("ListenAndServeQueue").Return([]sqs.ConceptUpdate{})
+	s3mock.callsMocked = true
+	s3mock.On("GetConceptAndTransactionID", "99247059-04ec-3abb-8693-a0b8951fdcab").Return().After(time.Second)
+	s3mock.On("GetConceptAndTransactionID", mock.Anything).Return()
+	mockSqsClient.conceptsQueue["2"] = "c28fa0b4-4245-11e8-842f-0ed5f89f718b"
+	go svc.ListenForNotifications(context.Background(), 1, 2, 10)
+	defer func() { done <- struct{}{} }()
+
+	assert.Eventually(t, func() bool {
+		_, ok := mockSqsClient.Queue()["2"]
+		return !ok
+	}, 500*time.Millisecond, 10*time.Millisecond, "fast update should not wait for the slow one")
+	assert.Contains(t, mockSqsClient.Queue(), "1")
+	assert.Eventually(t, func() bool {
+		return len(mockSqsClient.Queue()) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestAcquireInFlightSlots(t *testing.T) {
+	inFlight := make(chan struct{}, 12)
+	assert.Equal(t, 10, acquireInFlightSlots(context.Background(), inFlight, 10))
+	assert.Equal(t, 2, acquireInFlightSlots(context.Background(), inFlight, 10))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, 0, acquireInFlightSlots(ctx, inFlight, 10))
+
+	releaseInFlightSlots(inFlight, 3)
+	assert.Equal(t, 3, acquireInFlightSlots(context.Background(), inFlight, 10))
+}
+
+func TestAggregateService_ProcessConceptUpdate_ExtendsVisibilityWhileProcessing(t *testing.T) {
+	svc, s3mock, mockSqsClient, _, _, _, _ := setupTestServiceWithTimeout(200, payload, 5*time.Second)
+	svc.visibilityTimeout = 3 * time.Second
+	s3mock.callsMocked = true
+	s3mock.On("GetConceptAndTransactionID", "99247059-04ec-3abb-8693-a0b8951fdcab").Return().After(1500 * time.Millisecond)
+	receiptHandle := "2"
+	mockSqsClient.conceptsQueue[receiptHandle] = "99247059-04ec-3abb-8693-a0b8951fdcab"
+
+	err := svc.processConceptUpdate(context.Background(), sqs.ConceptUpdate{UUID: "99247059-04ec-3abb-8693-a0b8951fdcab", ReceiptHandle: &receiptHandle})
+	assert.NoError(t, err)
+	assert.NotContains(t, mockSqsClient.Queue(), receiptHandle)
+	assert.Equal(t, map[string]int{receiptHandle: 3}, mockSqsClient.Visibility())
+}
+
 func TestAggregateService_ProcessConceptUpdate_ContextTimeout(t *testing.T) {
 
 	svc, s3mock, _, _, _, _, _ := setupTestServiceWithTimeout(200, payload, time.Millisecond*10)
@@ -144,6 +228,95 @@ func TestAggregateService_ProcessConceptUpdate_ContextTimeout(t *testing.T) {
 	assert.EqualError(t, err, "context deadline exceeded")
 }
 
+func TestAggregateService_ProcessConceptUpdate_DeadLettersAfterMaxReceiveCount(t *testing.T) {
+	svc, _, mockSqsClient, _, _, _, _ := setupTestService(200, payload)
+	mockSqsClient.maxReceiveCount = 3
+	receiptHandle := "2"
+	poisonUUID := "45f278ef-91b2-45f7-9545-fbc79c1b4004"
+	expectedErr := "canonical concept 45f278ef-91b2-45f7-9545-fbc79c1b4004 not found in S3"
+	mockSqsClient.conceptsQueue[receiptHandle] = poisonUUID
+
+	err := svc.processConceptUpdate(context.Background(), sqs.ConceptUpdate{UUID: poisonUUID, ReceiptHandle: &receiptHandle, ReceiveCount: 3})
+	assert.EqualError(t, err, expectedErr)
+	assert.Contains(t, mockSqsClient.Queue(), receiptHandle)
+	assert.Empty(t, mockSqsClient.DeadLetterQueue())
+	assert.Equal(t, map[string]int{receiptHandle: 40}, mockSqsClient.Visibility())
+
+	err = svc.processConceptUpdate(context.Background(), sqs.ConceptUpdate{UUID: poisonUUID, ReceiptHandle: &receiptHandle, ReceiveCount: 4})
+	assert.EqualError(t, err, expectedErr)
+	assert.NotContains(t, mockSqsClient.Queue(), receiptHandle)
+	assert.Equal(t, map[string]string{poisonUUID: expectedErr}, mockSqsClient.DeadLetterQueue())
+}
+
+func TestAggregateService_ProcessConceptUpdate_QuarantinesPermanentFailures(t *testing.T) {
+	svc, _, mockSqsClient, _, _, _, _ := setupTestService(200, payload)
+	svc.concordances = &mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+				{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "Smartlogic"},
+			},
+		},
+	}
+	receiptHandle := "2"
+	mockSqsClient.conceptsQueue[receiptHandle] = "28090964-9997-4bc2-9638-7a11135aaff9"
+
+	err := svc.processConceptUpdate(context.Background(), sqs.ConceptUpdate{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", ReceiptHandle: &receiptHandle, ReceiveCount: 1})
+	assert.EqualError(t, err, "more than 1 primary authority")
+	assert.Equal(t, failure.Permanent, failure.KindOf(err))
+	assert.NotContains(t, mockSqsClient.Queue(), receiptHandle)
+	assert.Equal(t, map[string]string{"28090964-9997-4bc2-9638-7a11135aaff9": "more than 1 primary authority"}, mockSqsClient.DeadLetterQueue())
+	assert.Empty(t, mockSqsClient.Visibility())
+}
+
+func TestAggregateService_ProcessConceptUpdate_PausesWhenDownstreamUnavailable(t *testing.T) {
+	svc, _, mockSqsClient, _, _, _, _ := setupTestService(502, payload)
+	mockSqsClient.maxReceiveCount = 10
+	receiptHandle := "2"
+	mockSqsClient.conceptsQueue[receiptHandle] = "28090964-9997-4bc2-9638-7a11135aaff9"
+
+	assert.False(t, svc.health.isPaused())
+	err := svc.processConceptUpdate(context.Background(), sqs.ConceptUpdate{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", ReceiptHandle: &receiptHandle, ReceiveCount: 10})
+	assert.Error(t, err)
+	assert.Equal(t, failure.Unavailable, failure.KindOf(err))
+	assert.True(t, svc.health.isPaused())
+	assert.Contains(t, mockSqsClient.Queue(), receiptHandle)
+	assert.Empty(t, mockSqsClient.DeadLetterQueue())
+	assert.Equal(t, map[string]int{receiptHandle: 30}, mockSqsClient.Visibility())
+}
+
+func TestAggregateService_ProcessConceptUpdate_DeadLettersUnavailableAfterMaxReceiveCount(t *testing.T) {
+	svc, _, mockSqsClient, _, _, _, _ := setupTestService(503, payload)
+	mockSqsClient.maxReceiveCount = 3
+	receiptHandle := "2"
+	mockSqsClient.conceptsQueue[receiptHandle] = "28090964-9997-4bc2-9638-7a11135aaff9"
+
+	err := svc.processConceptUpdate(context.Background(), sqs.ConceptUpdate{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", ReceiptHandle: &receiptHandle, ReceiveCount: 4})
+	assert.Equal(t, failure.Unavailable, failure.KindOf(err))
+	assert.True(t, svc.health.isPaused(), "consumption should still pause while the service is unavailable")
+	assert.NotContains(t, mockSqsClient.Queue(), receiptHandle)
+	assert.Equal(t, map[string]string{"28090964-9997-4bc2-9638-7a11135aaff9": err.Error()}, mockSqsClient.DeadLetterQueue())
+	assert.Empty(t, mockSqsClient.Visibility())
+}
+
+func TestAggregateService_Backoff(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	testCases := []struct {
+		receiveCount int
+		expected     time.Duration
+	}{
+		{receiveCount: 0, expected: 10 * time.Second},
+		{receiveCount: 1, expected: 10 * time.Second},
+		{receiveCount: 2, expected: 20 * time.Second},
+		{receiveCount: 5, expected: 160 * time.Second},
+		{receiveCount: 8, expected: 15 * time.Minute},
+		{receiveCount: 100, expected: 15 * time.Minute},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, svc.backoff(tc.receiveCount), "receive count %d", tc.receiveCount)
+	}
+}
+
 func TestAggregateService_GetConcordedConcept_NoConcordance(t *testing.T) {
 	svc, _, _, _, _, _, _ := setupTestService(200, payload)
 
@@ -869,6 +1042,131 @@ func TestAggregateService_GetConcordedConcept_LoneTME(t *testing.T) {
 	assert.Equal(t, expectedConcept, c)
 }
 
+func TestAggregateService_GetConcordedConcept_NoPrimaryAuthorityUsesAuthorityPrecedence(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.concordances.(*mockConcordancesClient).concordances["c28fa0b4-4245-11e8-842f-0ed5f89f718b"] = []concordances.ConcordanceRecord{
+		{
+			UUID:      "99309d51-8969-4a1e-8346-d51f1981479b",
+			Authority: "TME",
+		},
+		{
+			UUID:      "c28fa0b4-4245-11e8-842f-0ed5f89f718b",
+			Authority: "FACTSET",
+		},
+	}
+
+	for i := 0; i < 20; i++ {
+		c, tid, err := svc.GetConcordedConcept(context.Background(), "c28fa0b4-4245-11e8-842f-0ed5f89f718b", "")
+		assert.NoError(t, err)
+		assert.Equal(t, "c28fa0b4-4245-11e8-842f-0ed5f89f718b", c.PrefUUID, "FACTSET takes precedence over TME")
+		assert.Equal(t, "tid_631", tid)
+		if assert.Len(t, c.SourceRepresentations, 2) {
+			assert.Equal(t, "TME", c.SourceRepresentations[0].Authority)
+			assert.Equal(t, "FACTSET", c.SourceRepresentations[1].Authority)
+		}
+	}
+}
+
+func TestAggregateService_FetchConcepts_BoundedConcurrency(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	store := &concurrentS3Client{started: make(chan string, 10), release: make(chan struct{})}
+	svc.nStore = store
+	svc.sourceFetchConcurrency = 3
+
+	var records []concordances.ConcordanceRecord
+	for i := 0; i < 10; i++ {
+		records = append(records, concordances.ConcordanceRecord{UUID: fmt.Sprintf("source-%d", i), Authority: "FACTSET"})
+	}
+
+	var fetched []fetchedConcept
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fetched, err = svc.fetchConcepts(context.Background(), "", records, time.Time{})
+	}()
+
+	// The first three fetches are held until released, so they all have to be in flight at once and no other can start.
+	for i := 0; i < 3; i++ {
+		<-store.started
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&store.running), "sources should not be fetched one at a time")
+	close(store.release)
+	<-done
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&store.maxActive))
+	if assert.Len(t, fetched, len(records)) {
+		for i, record := range records {
+			assert.True(t, fetched[i].found)
+			assert.Equal(t, record.UUID, fetched[i].concept.UUID)
+			assert.Equal(t, "tid_"+record.UUID, fetched[i].transactionID)
+		}
+	}
+}
+
+func TestAggregateService_FetchConcepts_ErrorCancelsOtherFetches(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	store := &concurrentS3Client{
+		delay:  time.Minute,
+		errors: map[string]error{"source-2": errors.New("access denied")},
+	}
+	svc.nStore = store
+
+	records := []concordances.ConcordanceRecord{{UUID: "source-1"}, {UUID: "source-2"}, {UUID: "source-3"}}
+	_, err := svc.fetchConcepts(context.Background(), "", records, time.Time{})
+	assert.EqualError(t, err, "access denied")
+}
+
+func TestAggregateService_FetchConcepts_CancelContext(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.nStore = &concurrentS3Client{delay: time.Minute}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := svc.fetchConcepts(ctx, "", []concordances.ConcordanceRecord{{UUID: "source-1"}, {UUID: "source-2"}}, time.Time{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAggregateService_GetConcordedConceptAsOf(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	day := func(d int) time.Time {
+		return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC)
+	}
+	svc.nStore = &versionedS3Client{
+		versions: map[string][]conceptVersion{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				{created: day(1), transactionID: "tid_sl_1", concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Old label", Authority: "Smartlogic", Type: "Person"}},
+				{created: day(3), transactionID: "tid_sl_2", concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "New label", Authority: "Smartlogic", Type: "Person"}},
+			},
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				{created: day(2), transactionID: "tid_tme_1", concept: ontology.SourceConcept{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", PrefLabel: "TME label", Authority: "FT-TME", Type: "Person"}},
+			},
+		},
+	}
+
+	c, tid, err := svc.GetConcordedConceptAsOf(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", day(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "tid_sl_1", tid)
+	assert.Equal(t, "Old label", c.PrefLabel)
+	assert.Len(t, c.SourceRepresentations, 2)
+
+	c, tid, err = svc.GetConcordedConceptAsOf(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", day(4))
+	assert.NoError(t, err)
+	assert.Equal(t, "tid_sl_2", tid)
+	assert.Equal(t, "New label", c.PrefLabel)
+
+	_, _, err = svc.GetConcordedConceptAsOf(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", day(1).Add(-time.Hour))
+	assert.EqualError(t, err, "canonical concept 28090964-9997-4bc2-9638-7a11135aaff9 not found in S3")
+}
+
+func TestAggregateService_GetConcordedConceptAsOf_NotSupported(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+
+	_, _, err := svc.GetConcordedConceptAsOf(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", time.Now())
+	assert.ErrorIs(t, err, ErrVersionsNotSupported)
+}
+
 func TestAggregateService_GetConcordedConcept_Memberships(t *testing.T) {
 	svc, _, _, _, _, _, _ := setupTestService(200, payload)
 	expectedConcept := transform.OldAggregatedConcept{
@@ -971,6 +1269,46 @@ func TestAggregateService_ProcessMessage_Success(t *testing.T) {
 	assert.Equal(t, 3, len(eventQueue.eventList))
 }
 
+func TestAggregateService_ProcessMessage_CoalescesConcurrentUpdates(t *testing.T) {
+	svc, s3mock, _, _, _, _, _ := setupTestService(200, payload)
+	s3mock.callsMocked = true
+	release := make(chan time.Time)
+	s3mock.On("GetConceptAndTransactionID", "28090964-9997-4bc2-9638-7a11135aaff9").Return().Once().WaitUntil(release)
+	s3mock.On("GetConceptAndTransactionID", mock.Anything).Return()
+
+	var wg sync.WaitGroup
+	process := func() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+			assert.NoError(t, err)
+		}()
+	}
+	process()
+	assert.Eventually(t, func() bool {
+		svc.serialiser.Lock()
+		defer svc.serialiser.Unlock()
+		_, running := svc.serialiser.keys["source:28090964-9997-4bc2-9638-7a11135aaff9"]
+		return running
+	}, time.Second, time.Millisecond)
+	process()
+	process()
+	waitForWaiters(t, svc.serialiser, "source:28090964-9997-4bc2-9638-7a11135aaff9", 2)
+	close(release)
+	wg.Wait()
+
+	mockWriter := svc.httpClient.(*mockHTTPClient)
+	var neo4jWrites int
+	for _, called := range mockWriter.called {
+		if strings.HasPrefix(called, neo4jUrl) {
+			neo4jWrites++
+		}
+	}
+	// the first update runs straight away and the two which arrived while it was running are written together
+	assert.Equal(t, 2, neo4jWrites)
+}
+
 func TestAggregateService_ProcessMessage_FinancialInstrumentsNotSentToEs(t *testing.T) {
 	svc, _, _, eventQueue, _, _, _ := setupTestService(200, payload)
 	err := svc.ProcessMessage(context.Background(), "6562674e-dbfa-4cb0-85b2-41b0948b7cc2", "")
@@ -1143,6 +1481,7 @@ func TestAggregateService_ProcessMessage_GenericWriterError(t *testing.T) {
 	err := svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
 	assert.Error(t, err)
 	assert.Equal(t, "Request to concepts-rw-neo4j/test-concepts/28090964-9997-4bc2-9638-7a11135aaff9 returned status: 503; skipping 28090964-9997-4bc2-9638-7a11135aaff9", err.Error())
+	assert.Equal(t, failure.Unavailable, failure.KindOf(err))
 }
 
 func TestAggregateService_ProcessMessage_GenericSqsError(t *testing.T) {
@@ -1205,6 +1544,47 @@ func TestAggregateService_ProcessMessage_S3CanonicalNotFound(t *testing.T) {
 	assert.EqualError(t, err, "canonical concept 45f278ef-91b2-45f7-9545-fbc79c1b4004 not found in S3")
 }
 
+// countingS3Client counts the concepts read from the store it wraps.
+type countingS3Client struct {
+	normalisedClient
+	reads int32
+}
+
+func (s *countingS3Client) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+	atomic.AddInt32(&s.reads, 1)
+	return s.normalisedClient.GetConceptAndTransactionID(ctx, publication, UUID)
+}
+
+func TestAggregateService_GetConcordedConcept_SoloConcept(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	store := &countingS3Client{normalisedClient: &versionedS3Client{
+		versions: map[string][]conceptVersion{
+			"c1f2b0a8-5cb5-4e2e-9d9e-1a3c2b6a1f10": {
+				{transactionID: "tid_factset", concept: ontology.SourceConcept{UUID: "c1f2b0a8-5cb5-4e2e-9d9e-1a3c2b6a1f10", PrefLabel: "FACTSET organisation", Authority: "FACTSET", AuthorityValue: "F-1", Type: "Organisation"}},
+			},
+		},
+	}}
+	svc.nStore = store
+
+	c, tid, err := svc.GetConcordedConcept(context.Background(), "c1f2b0a8-5cb5-4e2e-9d9e-1a3c2b6a1f10", "")
+	require.NoError(t, err)
+	assert.Equal(t, "tid_factset", tid)
+	assert.Equal(t, "FACTSET organisation", c.PrefLabel)
+	if assert.Len(t, c.SourceRepresentations, 1) {
+		assert.Equal(t, "FACTSET", c.SourceRepresentations[0].Authority)
+	}
+	assert.Equal(t, int32(1), store.reads, "the solo concept should only be read once")
+
+	records := []concordances.ConcordanceRecord{{UUID: "c1f2b0a8-5cb5-4e2e-9d9e-1a3c2b6a1f10", Solo: true}}
+	_, err = svc.fetchSoloConcept(context.Background(), "", records, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "FACTSET", records[0].Authority)
+	primary, sources, _, err := bucketConcordances(records, svc.primaryAuthorityRules)
+	require.NoError(t, err)
+	assert.Empty(t, primary.UUID, "a solo FACTSET concept should not be taken for a primary authority concept")
+	assert.Len(t, sources, 1)
+}
+
 func TestAggregateService_ProcessMessage_CancelContext(t *testing.T) {
 	svc, _, _, _, _, _, _ := setupTestService(200, payload)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -1886,11 +2266,16 @@ func setupTestServiceWithTimeout(clientStatusCode int, writerResponse string, ti
 	feedback := make(chan bool)
 	done := make(chan struct{})
 
-	svc := NewService(s3mock, externalS3Mock, conceptsQueue, eventsSNS, concordClient, kinesis,
+	svc := NewService(s3mock, externalS3Mock, nil, conceptsQueue, eventsSNS, concordClient, kinesis,
 		neo4jUrl,
 		esUrl,
 		varnishPurgerUrl,
 		[]string{"Person", "Brand", "PublicCompany", "Organisation"},
+		DefaultPurgeRules(),
+		nil,
+		[]string{"Smartlogic", "ManagedLocation", "FACTSET", "TME"},
+		DefaultPrimaryAuthorityRules(),
+		DefaultCanonicalValidators(),
 		&mockHTTPClient{
 			resp:       writerResponse,
 			statusCode: clientStatusCode,
@@ -1900,7 +2285,11 @@ func setupTestServiceWithTimeout(clientStatusCode int, writerResponse string, ti
 		feedback,
 		done,
 		timeout,
+		30*time.Second,
+		4,
+		ValidationOff,
 		false,
+		nil,
 	)
 
 	feedback <- true
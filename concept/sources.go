@@ -0,0 +1,73 @@
+package concept
+
+import (
+	"context"
+	"time"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+// SourceEntry is a concordance record along with the source concept read for it from the concept store.
+// Key is where the concept is kept in the store, and is empty when the store cannot tell.
+// When the stored concept cannot be decoded, Error tells why and Document is the stored document, if the store can return it.
+type SourceEntry struct {
+	Record        concordances.ConcordanceRecord `json:"record"`
+	Found         bool                           `json:"found"`
+	Key           string                         `json:"key,omitempty"`
+	TransactionID string                         `json:"transactionID,omitempty"`
+	Concept       *ontology.SourceConcept        `json:"concept,omitempty"`
+	Error         string                         `json:"error,omitempty"`
+	Document      string                         `json:"document,omitempty"`
+}
+
+// ConceptSources are the concordance records of a concept, in the order the concordance store returned them,
+// and the raw source concepts stored for them, before any aggregation.
+type ConceptSources struct {
+	UUID        string        `json:"uuid"`
+	Publication string        `json:"publication,omitempty"`
+	Sources     []SourceEntry `json:"sources"`
+}
+
+// GetConceptSources reads the concordance records of a concept and the source concept of each of them, as they are stored.
+// The records are returned as the concordance store sent them, so the primary authority is not picked and nothing is validated.
+// A source concept which cannot be decoded is reported in its own entry rather than failing the others.
+func (s *AggregateService) GetConceptSources(ctx context.Context, UUID string, bookmark string) (ConceptSources, error) {
+	cleanedUUID, publication, err := extractIdentifiersFromKey(UUID)
+	if err != nil {
+		return ConceptSources{}, failure.Wrap(failure.Validation, err)
+	}
+	records, err := s.concordances.GetConcordance(ctx, cleanedUUID, bookmark)
+	if err != nil {
+		return ConceptSources{}, err
+	}
+	fetched, err := s.fetchConcepts(ctx, publication, records, time.Time{})
+	if err != nil {
+		return ConceptSources{}, err
+	}
+
+	var store normalisedClient = s.nStore
+	if publication != "" {
+		store = s.externalNormalisedStore
+	}
+	keyed, _ := store.(keyedClient)
+
+	sources := make([]SourceEntry, 0, len(records))
+	for i, record := range records {
+		entry := SourceEntry{Record: record, Found: fetched[i].found, TransactionID: fetched[i].transactionID}
+		if err = fetched[i].decodeErr; err != nil {
+			entry.Error = err.Error()
+			entry.Document = string(fetched[i].document)
+		} else if fetched[i].found {
+			concept := fetched[i].concept
+			entry.Concept = &concept
+		}
+		if keyed != nil {
+			entry.Key = keyed.ObjectKey(publication, record.UUID)
+		}
+		sources = append(sources, entry)
+	}
+	return ConceptSources{UUID: cleanedUUID, Publication: publication, Sources: sources}, nil
+}
@@ -0,0 +1,131 @@
+package concept
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+	"github.com/Financial-Times/cm-graph-ontology/v2/transform"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+)
+
+// bookmarkedConcordancesClient records the bookmark of the last request.
+type bookmarkedConcordancesClient struct {
+	mockConcordancesClient
+	bookmark string
+}
+
+func (c *bookmarkedConcordancesClient) GetConcordance(ctx context.Context, uuid string, bookmark string) ([]concordances.ConcordanceRecord, error) {
+	c.bookmark = bookmark
+	return c.mockConcordancesClient.GetConcordance(ctx, uuid, bookmark)
+}
+
+func newSourcesS3Client(key string, authority string) *mockS3Client {
+	return &mockS3Client{
+		concepts: map[string]struct {
+			transactionID string
+			concept       transform.OldConcept
+		}{
+			key: {
+				transactionID: "tid_" + authority,
+				concept: transform.OldConcept{
+					UUID:      "28090964-9997-4bc2-9638-7a11135aaff9",
+					PrefLabel: authority + " label",
+					Authority: authority,
+					Type:      "Person",
+				},
+			},
+		},
+	}
+}
+
+func TestAggregateService_GetConceptSources(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	records := []concordances.ConcordanceRecord{
+		{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+		{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", AuthorityValue: "TME-1"},
+	}
+	clusters := &bookmarkedConcordancesClient{mockConcordancesClient: mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{"28090964-9997-4bc2-9638-7a11135aaff9": records},
+	}}
+	svc.concordances = clusters
+	svc.nStore = newSourcesS3Client("28090964-9997-4bc2-9638-7a11135aaff9", "Smartlogic")
+
+	sources, err := svc.GetConceptSources(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "FB:kcwQnrEEnFpfSJ2PtiykK/JNh8oBozhIkA==")
+	require.NoError(t, err)
+	assert.Equal(t, "FB:kcwQnrEEnFpfSJ2PtiykK/JNh8oBozhIkA==", clusters.bookmark)
+
+	assert.Equal(t, "28090964-9997-4bc2-9638-7a11135aaff9", sources.UUID)
+	assert.Empty(t, sources.Publication)
+	require.Len(t, sources.Sources, 2)
+	assert.Equal(t, SourceEntry{
+		Record:        records[0],
+		Found:         true,
+		Key:           "28090964-9997-4bc2-9638-7a11135aaff9",
+		TransactionID: "tid_Smartlogic",
+		Concept:       &ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Smartlogic label", Authority: "Smartlogic", Type: "Person"},
+	}, sources.Sources[0])
+	assert.Equal(t, SourceEntry{
+		Record: records[1],
+		Key:    "34a571fb-d779-4610-a7ba-2e127676db4d",
+	}, sources.Sources[1], "a source missing from the store should be listed as not found")
+}
+
+func TestAggregateService_GetConceptSources_Publication(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.concordances = &mockConcordancesClient{}
+	svc.externalNormalisedStore = newPublicationRouter(&mockS3Client{}, []PublicationStore{{
+		Route: PublicationRoute{Publication: "8e6c705e-1132-42a2-8db0-c295e29e8658", Bucket: "sv-concepts", Region: "us-east-1", Prefix: "concepts"},
+		Store: newSourcesS3Client("concepts/28090964-9997-4bc2-9638-7a11135aaff9", "Smartlogic"),
+	}})
+
+	sources, err := svc.GetConceptSources(context.Background(), "8e6c705e-1132-42a2-8db0-c295e29e8658-28090964-9997-4bc2-9638-7a11135aaff9", "")
+	require.NoError(t, err)
+	assert.Equal(t, "28090964-9997-4bc2-9638-7a11135aaff9", sources.UUID)
+	assert.Equal(t, "8e6c705e-1132-42a2-8db0-c295e29e8658", sources.Publication)
+	require.Len(t, sources.Sources, 1)
+	assert.True(t, sources.Sources[0].Record.Solo, "a solo record should be returned as the concordance store sent it")
+	assert.True(t, sources.Sources[0].Found)
+	assert.Equal(t, "concepts/28090964-9997-4bc2-9638-7a11135aaff9", sources.Sources[0].Key)
+	assert.Equal(t, "tid_Smartlogic", sources.Sources[0].TransactionID)
+}
+
+func TestAggregateService_GetConceptSources_UndecodableSource(t *testing.T) {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	records := []concordances.ConcordanceRecord{
+		{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+		{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", AuthorityValue: "TME-1"},
+	}
+	svc.concordances = &mockConcordancesClient{
+		concordances: map[string][]concordances.ConcordanceRecord{"28090964-9997-4bc2-9638-7a11135aaff9": records},
+	}
+	svc.nStore = &documentS3Client{
+		documents: map[string]storedDocument{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				transactionID: "tid_sl",
+				body:          `{"uuid": "28090964-9997-4bc2-9638-7a11135aaff9", "prefLabel": "Primary label", "type": "Person", "authority": "Smartlogic"}`,
+			},
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				transactionID: "tid_tme",
+				body:          `{"uuid": "34a571fb-d779-4610-a7ba-2e127676db4d", "prefLabel": 1970}`,
+			},
+		},
+	}
+
+	sources, err := svc.GetConceptSources(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	require.NoError(t, err, "a source which cannot be decoded should not fail the other sources")
+	require.Len(t, sources.Sources, 2)
+	assert.Equal(t, &ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Primary label", Authority: "Smartlogic", Type: "Person"}, sources.Sources[0].Concept)
+	assert.Empty(t, sources.Sources[0].Error)
+
+	undecodable := sources.Sources[1]
+	assert.True(t, undecodable.Found)
+	assert.Equal(t, "tid_tme", undecodable.TransactionID)
+	assert.Nil(t, undecodable.Concept)
+	assert.Contains(t, undecodable.Error, "cannot unmarshal number")
+	assert.Equal(t, `{"uuid": "34a571fb-d779-4610-a7ba-2e127676db4d", "prefLabel": 1970}`, undecodable.Document)
+}
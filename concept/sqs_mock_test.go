@@ -13,18 +13,33 @@ import (
 
 type mockSQSClient struct {
 	mock.Mock
-	conceptsQueue map[string]string
-	s             sync.RWMutex
-	err           error
+	conceptsQueue   map[string]string
+	received        map[string]bool
+	deadLetterQueue map[string]string
+	visibility      map[string]int
+	maxReceiveCount int
+	s               sync.RWMutex
+	err             error
 }
 
-func (c *mockSQSClient) ListenAndServeQueue(ctx context.Context) []sqs.ConceptUpdate {
+// ListenAndServeQueue returns the messages which are not already being processed, as SQS hides them until their visibility timeout expires.
+func (c *mockSQSClient) ListenAndServeQueue(ctx context.Context, maxMessages int) []sqs.ConceptUpdate {
 	c.s.Lock()
 	defer c.s.Unlock()
 	c.Called()
+	if c.received == nil {
+		c.received = map[string]bool{}
+	}
 	q := c.conceptsQueue
 	notifications := []sqs.ConceptUpdate{}
 	for msgTag, UUID := range q {
+		if len(notifications) == maxMessages {
+			break
+		}
+		if c.received[msgTag] {
+			continue
+		}
+		c.received[msgTag] = true
 		notifications = append(notifications, sqs.ConceptUpdate{
 			UUID:          UUID,
 			Bookmark:      "",
@@ -39,15 +54,73 @@ func (c *mockSQSClient) RemoveMessageFromQueue(ctx context.Context, receiptHandl
 	defer c.s.Unlock()
 	if _, ok := c.conceptsQueue[*receiptHandle]; ok {
 		delete(c.conceptsQueue, *receiptHandle)
+		delete(c.received, *receiptHandle)
 		return nil
 	}
 	return errors.New("Receipt handle not present on conceptsQueue")
 }
 
+func (c *mockSQSClient) DeadLetterMessage(ctx context.Context, update sqs.ConceptUpdate, transactionID string, reason string) (bool, error) {
+	c.s.Lock()
+	defer c.s.Unlock()
+	if update.ReceiveCount <= c.maxReceiveCount {
+		return false, nil
+	}
+	if c.deadLetterQueue == nil {
+		c.deadLetterQueue = map[string]string{}
+	}
+	c.deadLetterQueue[update.UUID] = reason
+	delete(c.conceptsQueue, *update.ReceiptHandle)
+	return true, nil
+}
+
+func (c *mockSQSClient) QuarantineMessage(ctx context.Context, update sqs.ConceptUpdate, transactionID string, reason string) error {
+	c.s.Lock()
+	defer c.s.Unlock()
+	if c.deadLetterQueue == nil {
+		c.deadLetterQueue = map[string]string{}
+	}
+	c.deadLetterQueue[update.UUID] = reason
+	delete(c.conceptsQueue, *update.ReceiptHandle)
+	return nil
+}
+
+func (c *mockSQSClient) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int) error {
+	c.s.Lock()
+	defer c.s.Unlock()
+	if receiptHandle == nil {
+		return errors.New("Receipt handle not provided")
+	}
+	if c.visibility == nil {
+		c.visibility = map[string]int{}
+	}
+	c.visibility[*receiptHandle] = visibilityTimeout
+	if visibilityTimeout == 0 {
+		delete(c.received, *receiptHandle)
+	}
+	return nil
+}
+
+func (c *mockSQSClient) Visibility() map[string]int {
+	c.s.RLock()
+	defer c.s.RUnlock()
+	return c.visibility
+}
+
+func (c *mockSQSClient) DeadLetterQueue() map[string]string {
+	c.s.RLock()
+	defer c.s.RUnlock()
+	return c.deadLetterQueue
+}
+
 func (c *mockSQSClient) Queue() map[string]string {
 	c.s.RLock()
 	defer c.s.RUnlock()
-	return c.conceptsQueue
+	queue := make(map[string]string, len(c.conceptsQueue))
+	for receiptHandle, UUID := range c.conceptsQueue {
+		queue[receiptHandle] = UUID
+	}
+	return queue
 }
 
 func (c *mockSQSClient) Healthcheck() fthealth.Check {
@@ -0,0 +1,212 @@
+package concept
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Financial-Times/go-logger"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+// ValidationMode tells what to do with source concepts which do not match their schema.
+type ValidationMode string
+
+const (
+	// ValidationOff aggregates source concepts without validating them.
+	ValidationOff ValidationMode = "off"
+	// ValidationReject fails the aggregation when any of the source concepts is invalid.
+	ValidationReject ValidationMode = "reject"
+	// ValidationExclude aggregates invalid source concepts as if they were not found in S3, so only their concordance is kept.
+	// An invalid primary concept still fails the aggregation, as there is nothing to aggregate the sources into.
+	ValidationExclude ValidationMode = "exclude"
+)
+
+// ParseValidationMode returns the validation mode with the given name.
+func ParseValidationMode(name string) (ValidationMode, error) {
+	switch mode := ValidationMode(name); mode {
+	case ValidationOff, ValidationReject, ValidationExclude:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown validation mode %q, expected one of %s, %s or %s", name, ValidationOff, ValidationReject, ValidationExclude)
+	}
+}
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+const baseSchema = "source.json"
+
+// typeSchemas maps the concept types with extra fields to their schema. Source concepts of other types are validated against the base schema.
+var typeSchemas = map[string]string{
+	"Person":        "person.json",
+	"Organisation":  "organisation.json",
+	"PublicCompany": "organisation.json",
+	"Location":      "location.json",
+}
+
+var sourceSchemas = mustCompileSchemas()
+
+func mustCompileSchemas() map[string]*jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		f, err := schemaFiles.Open(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		err = compiler.AddResource(entry.Name(), f)
+		f.Close()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	schemas := map[string]*jsonschema.Schema{baseSchema: compiler.MustCompile(baseSchema)}
+	for _, name := range typeSchemas {
+		schemas[name] = compiler.MustCompile(name)
+	}
+	return schemas
+}
+
+// FieldError is a field of a source concept which does not match its schema.
+// Field is a JSON pointer to the field, which is empty when the error is about the whole concept.
+type FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// InvalidSource lists the fields of a source concept which do not match its schema.
+type InvalidSource struct {
+	UUID   string       `json:"uuid"`
+	Type   string       `json:"type"`
+	Errors []FieldError `json:"errors"`
+}
+
+// InvalidSourcesError is returned when the aggregation is rejected because of invalid source concepts.
+type InvalidSourcesError struct {
+	Sources []InvalidSource
+}
+
+func (e *InvalidSourcesError) Error() string {
+	var sources []string
+	for _, source := range e.Sources {
+		var fields []string
+		for _, fieldErr := range source.Errors {
+			if fieldErr.Field == "" {
+				fields = append(fields, fieldErr.Error)
+				continue
+			}
+			fields = append(fields, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Error))
+		}
+		sources = append(sources, fmt.Sprintf("%s (%s)", source.UUID, strings.Join(fields, ", ")))
+	}
+	return fmt.Sprintf("invalid source concepts: %s", strings.Join(sources, "; "))
+}
+
+// validateSource checks the JSON document of a source concept, as it is stored, against the schema of its type.
+// It returns the fields which do not match the schema, or nothing when the concept is valid.
+func validateSource(document []byte) ([]FieldError, error) {
+	var doc interface{}
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, failure.Wrap(failure.Validation, err)
+	}
+	var conceptType string
+	if fields, ok := doc.(map[string]interface{}); ok {
+		conceptType, _ = fields["type"].(string)
+	}
+	schema, ok := sourceSchemas[typeSchemas[conceptType]]
+	if !ok {
+		schema = sourceSchemas[baseSchema]
+	}
+
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+	return fieldErrors(validationErr), nil
+}
+
+// fieldErrors flattens a validation error into the errors without causes, as the others only say that their causes failed.
+func fieldErrors(err *jsonschema.ValidationError) []FieldError {
+	if len(err.Causes) == 0 {
+		return []FieldError{{Field: err.InstanceLocation, Error: err.Message}}
+	}
+	var errs []FieldError
+	for _, cause := range err.Causes {
+		errs = append(errs, fieldErrors(cause)...)
+	}
+	return errs
+}
+
+// sourceDocument returns the JSON document of a fetched concept as it is stored,
+// or the concept encoded back into JSON when the store only returned the decoded concept.
+func sourceDocument(fetched fetchedConcept) ([]byte, error) {
+	if fetched.document != nil {
+		return fetched.document, nil
+	}
+	return json.Marshal(fetched.concept)
+}
+
+// validateSources checks the fetched concepts of the given concordance records according to the validation mode.
+// Excluded sources are marked as not found. When hasPrimary is set, the last record is the primary concept, which is never excluded.
+// A document which matches its schema, or is not validated, but cannot be decoded still fails the aggregation.
+func (s *AggregateService) validateSources(UUID string, records []concordances.ConcordanceRecord, fetched []fetchedConcept, hasPrimary bool) error {
+	validate := s.sourceValidation != "" && s.sourceValidation != ValidationOff
+
+	var invalid []InvalidSource
+	for i := range fetched {
+		if !fetched[i].found {
+			continue
+		}
+		var errs []FieldError
+		if validate {
+			document, err := sourceDocument(fetched[i])
+			if err != nil {
+				return err
+			}
+			if errs, err = validateSource(document); err != nil {
+				return err
+			}
+		}
+		if len(errs) == 0 {
+			if err := fetched[i].decodeErr; err != nil {
+				logger.WithError(err).WithUUID(UUID).WithField("sourceUUID", records[i].UUID).Error("Cannot unmarshal source document into a concept")
+				return failure.Wrap(failure.Validation, err)
+			}
+			continue
+		}
+
+		logEntry := logger.WithUUID(UUID).
+			WithField("sourceUUID", records[i].UUID).
+			WithField("validationErrors", errs).
+			WithField("alert_tag", "AggregateConceptTransformerInvalidSourceConcept")
+		if s.sourceValidation == ValidationExclude && !(hasPrimary && i == len(fetched)-1) {
+			logEntry.Warn("Excluding source concept which does not match its schema")
+			fetched[i].found = false
+			fetched[i].concept = ontology.SourceConcept{}
+			fetched[i].decodeErr = nil
+			continue
+		}
+		logEntry.Error("Source concept does not match its schema")
+		invalid = append(invalid, InvalidSource{UUID: records[i].UUID, Type: fetched[i].concept.Type, Errors: errs})
+	}
+	if len(invalid) > 0 {
+		return failure.Wrap(failure.Validation, &InvalidSourcesError{Sources: invalid})
+	}
+	return nil
+}
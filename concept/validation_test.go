@@ -0,0 +1,207 @@
+package concept
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+	"github.com/Financial-Times/cm-graph-ontology/v2/transform"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+func TestValidateSource(t *testing.T) {
+	testCases := map[string]struct {
+		concept        ontology.SourceConcept
+		document       string
+		expectedErrors []FieldError
+	}{
+		"Valid concept": {
+			concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Person", Type: "Person", Authority: "Smartlogic", Aliases: []string{"Alias"}},
+		},
+		"Valid concept of a type without its own schema": {
+			concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: "Brand", Type: "Brand", Authority: "Smartlogic"},
+		},
+		"Missing fields": {
+			concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Type: "Person"},
+			expectedErrors: []FieldError{
+				{Field: "", Error: "missing properties: 'prefLabel', 'authority'"},
+			},
+		},
+		"Invalid fields": {
+			concept: ontology.SourceConcept{UUID: "not-a-uuid", PrefLabel: "Organisation", Type: "Organisation", Authority: "FACTSET", Aliases: []string{"Alias", ""}},
+			expectedErrors: []FieldError{
+				{Field: "/uuid", Error: "does not match pattern '^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$'"},
+				{Field: "/aliases/1", Error: "length must be >= 1, but got 0"},
+			},
+		},
+		"Field of the wrong type, which decodes into a zero value": {
+			document: `{"uuid": "28090964-9997-4bc2-9638-7a11135aaff9", "prefLabel": "Person", "type": "Person", "authority": "Smartlogic", "birthYear": "1970"}`,
+			expectedErrors: []FieldError{
+				{Field: "/birthYear", Error: "expected integer, but got string"},
+			},
+		},
+		"Wrong type of a field of the base schema": {
+			document: `{"uuid": "28090964-9997-4bc2-9638-7a11135aaff9", "prefLabel": "Brand", "type": "Brand", "authority": "Smartlogic", "aliases": "Alias"}`,
+			expectedErrors: []FieldError{
+				{Field: "/aliases", Error: "expected array, but got string"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			document := []byte(tc.document)
+			if tc.document == "" {
+				var err error
+				document, err = json.Marshal(tc.concept)
+				require.NoError(t, err)
+			}
+			errs, err := validateSource(document)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expectedErrors, errs)
+		})
+	}
+}
+
+func TestParseValidationMode(t *testing.T) {
+	for _, name := range []string{"off", "reject", "exclude"} {
+		mode, err := ParseValidationMode(name)
+		assert.NoError(t, err)
+		assert.Equal(t, ValidationMode(name), mode)
+	}
+
+	_, err := ParseValidationMode("quarantine")
+	assert.EqualError(t, err, `unknown validation mode "quarantine", expected one of off, reject or exclude`)
+}
+
+func setupValidationTest(mode ValidationMode, primaryLabel string, sourceLabel string) *AggregateService {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.sourceValidation = mode
+	svc.concordances.(*mockConcordancesClient).concordances["28090964-9997-4bc2-9638-7a11135aaff9"] = []concordances.ConcordanceRecord{
+		{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+		{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", AuthorityValue: "TME-1"},
+	}
+	svc.nStore = &versionedS3Client{
+		versions: map[string][]conceptVersion{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				{transactionID: "tid_sl", concept: ontology.SourceConcept{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", PrefLabel: primaryLabel, Authority: "Smartlogic", Type: "Person"}},
+			},
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				{transactionID: "tid_tme", concept: ontology.SourceConcept{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", PrefLabel: sourceLabel, Authority: "FT-TME", Type: "Person"}},
+			},
+		},
+	}
+	return svc
+}
+
+func TestAggregateService_GetConcordedConcept_RejectsInvalidSources(t *testing.T) {
+	svc := setupValidationTest(ValidationReject, "Primary label", "")
+
+	_, _, err := svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	assert.Equal(t, failure.Validation, failure.KindOf(err))
+	var invalid *InvalidSourcesError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, []InvalidSource{{
+		UUID:   "34a571fb-d779-4610-a7ba-2e127676db4d",
+		Type:   "Person",
+		Errors: []FieldError{{Field: "", Error: "missing properties: 'prefLabel'"}},
+	}}, invalid.Sources)
+	assert.EqualError(t, err, "invalid source concepts: 34a571fb-d779-4610-a7ba-2e127676db4d (missing properties: 'prefLabel')")
+}
+
+func TestAggregateService_GetConcordedConcept_ExcludesInvalidSources(t *testing.T) {
+	svc := setupValidationTest(ValidationExclude, "Primary label", "")
+
+	c, tid, err := svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	require.NoError(t, err)
+	assert.Equal(t, "tid_sl", tid)
+	assert.Equal(t, "Primary label", c.PrefLabel)
+	if assert.Len(t, c.SourceRepresentations, 2) {
+		excluded := c.SourceRepresentations[0]
+		assert.Equal(t, "34a571fb-d779-4610-a7ba-2e127676db4d", excluded.UUID)
+		assert.Equal(t, "Thing", excluded.Type, "an excluded source should only keep its concordance")
+		assert.Equal(t, "TME-1", excluded.AuthorityValue)
+	}
+}
+
+func TestAggregateService_GetConcordedConcept_NeverExcludesPrimary(t *testing.T) {
+	svc := setupValidationTest(ValidationExclude, "", "Source label")
+
+	_, _, err := svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	var invalid *InvalidSourcesError
+	require.True(t, errors.As(err, &invalid))
+	if assert.Len(t, invalid.Sources, 1) {
+		assert.Equal(t, "28090964-9997-4bc2-9638-7a11135aaff9", invalid.Sources[0].UUID)
+	}
+}
+
+func TestAggregateService_GetConcordedConcept_ValidationOff(t *testing.T) {
+	svc := setupValidationTest(ValidationOff, "", "")
+
+	_, _, err := svc.GetConcordedConcept(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	assert.NoError(t, err)
+}
+
+// setupStoredDocumentTest serves a valid primary concept and a source whose stored birthYear is a string,
+// which the schema rejects and which cannot be decoded into a concept either.
+func setupStoredDocumentTest(mode ValidationMode) *AggregateService {
+	svc, _, _, _, _, _, _ := setupTestService(200, payload)
+	svc.sourceValidation = mode
+	svc.concordances.(*mockConcordancesClient).concordances["28090964-9997-4bc2-9638-7a11135aaff9"] = []concordances.ConcordanceRecord{
+		{UUID: "28090964-9997-4bc2-9638-7a11135aaff9", Authority: "Smartlogic"},
+		{UUID: "34a571fb-d779-4610-a7ba-2e127676db4d", Authority: "FT-TME", AuthorityValue: "TME-1"},
+	}
+	svc.nStore = &documentS3Client{
+		documents: map[string]storedDocument{
+			"28090964-9997-4bc2-9638-7a11135aaff9": {
+				transactionID: "tid_sl",
+				body:          `{"uuid": "28090964-9997-4bc2-9638-7a11135aaff9", "prefLabel": "Primary label", "type": "Person", "authority": "Smartlogic", "authorityValue": "28090964-9997-4bc2-9638-7a11135aaff9"}`,
+			},
+			"34a571fb-d779-4610-a7ba-2e127676db4d": {
+				transactionID: "tid_tme",
+				body:          `{"uuid": "34a571fb-d779-4610-a7ba-2e127676db4d", "prefLabel": "Source label", "type": "Person", "authority": "FT-TME", "authorityValue": "TME-1", "birthYear": "1970"}`,
+			},
+		},
+	}
+	return svc
+}
+
+func TestAggregateService_ProcessMessage_RejectsInvalidStoredDocument(t *testing.T) {
+	svc := setupStoredDocumentTest(ValidationReject)
+
+	err := svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	assert.Equal(t, failure.Validation, failure.KindOf(err))
+	var invalid *InvalidSourcesError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, []InvalidSource{{
+		UUID:   "34a571fb-d779-4610-a7ba-2e127676db4d",
+		Type:   "Person",
+		Errors: []FieldError{{Field: "/birthYear", Error: "expected integer, but got string"}},
+	}}, invalid.Sources)
+	assert.Empty(t, svc.httpClient.(*mockHTTPClient).called, "nothing should be written for a rejected concept")
+}
+
+func TestAggregateService_ProcessMessage_ExcludesInvalidStoredDocument(t *testing.T) {
+	svc := setupStoredDocumentTest(ValidationExclude)
+
+	err := svc.ProcessMessage(context.Background(), "28090964-9997-4bc2-9638-7a11135aaff9", "")
+	require.NoError(t, err)
+	mockWriter := svc.httpClient.(*mockHTTPClient)
+	require.Contains(t, mockWriter.called, "concepts-rw-neo4j/people/28090964-9997-4bc2-9638-7a11135aaff9")
+
+	var written transform.OldAggregatedConcept
+	require.NoError(t, json.NewDecoder(mockWriter.capturedBody).Decode(&written))
+	assert.Equal(t, "Primary label", written.PrefLabel)
+	if assert.Len(t, written.SourceRepresentations, 2) {
+		excluded := written.SourceRepresentations[0]
+		assert.Equal(t, "34a571fb-d779-4610-a7ba-2e127676db4d", excluded.UUID)
+		assert.Equal(t, "Thing", excluded.Type, "an excluded source should only keep its concordance")
+	}
+}
@@ -0,0 +1,81 @@
+package concordances
+
+import (
+	"context"
+	"time"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/lru"
+)
+
+type cachedCluster struct {
+	records  []ConcordanceRecord
+	bookmark string
+}
+
+// CachedClient keeps the most recently read concordance clusters in memory, stored under the UUID of each of their members
+// so that aggregating the other members of a cluster does not read it again.
+// A cached cluster is only returned for requests without a bookmark or with the bookmark it was read with,
+// as a new bookmark means the concordances have just been changed.
+type CachedClient struct {
+	client Client
+	cache  *lru.Cache[string, cachedCluster]
+	hits   metrics.Counter
+	misses metrics.Counter
+}
+
+// NewCachedClient caches up to size clusters read through client, each for at most ttl.
+// Cache hits and misses are counted in the default metrics registry.
+func NewCachedClient(client Client, size int, ttl time.Duration) *CachedClient {
+	return &CachedClient{
+		client: client,
+		cache:  lru.New[string, cachedCluster](size, ttl),
+		hits:   metrics.GetOrRegisterCounter("concordances.cache.hits", metrics.DefaultRegistry),
+		misses: metrics.GetOrRegisterCounter("concordances.cache.misses", metrics.DefaultRegistry),
+	}
+}
+
+func (c *CachedClient) GetConcordance(ctx context.Context, uuid string, bookmark string) ([]ConcordanceRecord, error) {
+	cached, ok := c.cache.Get(uuid)
+	if ok && (bookmark == "" || bookmark == cached.bookmark) {
+		c.hits.Inc(1)
+		return copyRecords(cached.records), nil
+	}
+
+	c.misses.Inc(1)
+	records, err := c.client.GetConcordance(ctx, uuid, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	// members which have left the cluster must not keep finding it in the cache
+	members := map[string]bool{uuid: true}
+	for _, record := range records {
+		members[record.UUID] = true
+	}
+	for _, record := range cached.records {
+		if !members[record.UUID] {
+			c.cache.Remove(record.UUID)
+		}
+	}
+
+	cluster := cachedCluster{records: copyRecords(records), bookmark: bookmark}
+	for member := range members {
+		c.cache.Add(member, cluster)
+	}
+	return records, nil
+}
+
+func (c *CachedClient) Healthcheck() fthealth.Check {
+	return c.client.Healthcheck()
+}
+
+// copyRecords keeps the cached clusters safe from changes made by callers to the records they are given.
+func copyRecords(records []ConcordanceRecord) []ConcordanceRecord {
+	if records == nil {
+		return nil
+	}
+	return append(make([]ConcordanceRecord, 0, len(records)), records...)
+}
@@ -0,0 +1,100 @@
+package concordances
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clusterClient returns the cluster each UUID belongs to, which can change between requests, and records the requests.
+type clusterClient struct {
+	clusters  map[string][]ConcordanceRecord
+	bookmarks []string
+	err       error
+}
+
+func (c *clusterClient) GetConcordance(ctx context.Context, uuid string, bookmark string) ([]ConcordanceRecord, error) {
+	c.bookmarks = append(c.bookmarks, bookmark)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.clusters[uuid], nil
+}
+
+func (c *clusterClient) Healthcheck() fthealth.Check {
+	return fthealth.Check{ID: "cluster-client"}
+}
+
+func TestCachedClient_GetConcordance(t *testing.T) {
+	cluster := []ConcordanceRecord{
+		{UUID: "a", Authority: "Smartlogic"},
+		{UUID: "b", Authority: "FACTSET", AuthorityValue: "F-1"},
+	}
+	client := &clusterClient{clusters: map[string][]ConcordanceRecord{"a": cluster, "b": cluster}}
+	cached := NewCachedClient(client, 10, time.Minute)
+
+	records, err := cached.GetConcordance(context.Background(), "a", "bookmark-1")
+	require.NoError(t, err)
+	assert.Equal(t, cluster, records)
+
+	records, err = cached.GetConcordance(context.Background(), "b", "")
+	require.NoError(t, err)
+	assert.Equal(t, cluster, records, "the cluster should be cached under every member")
+
+	_, err = cached.GetConcordance(context.Background(), "b", "bookmark-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bookmark-1"}, client.bookmarks, "requests with the same bookmark should be served from the cache")
+
+	records[0].Authority = "changed"
+	records, _ = cached.GetConcordance(context.Background(), "a", "")
+	assert.Equal(t, "Smartlogic", records[0].Authority, "changes to returned records should not reach the cache")
+}
+
+func TestCachedClient_GetConcordance_NewBookmark(t *testing.T) {
+	client := &clusterClient{clusters: map[string][]ConcordanceRecord{
+		"a": {{UUID: "a", Authority: "Smartlogic"}, {UUID: "b", Authority: "FACTSET"}},
+	}}
+	cached := NewCachedClient(client, 10, time.Minute)
+
+	_, err := cached.GetConcordance(context.Background(), "a", "bookmark-1")
+	require.NoError(t, err)
+
+	// b is unconcorded from a
+	client.clusters = map[string][]ConcordanceRecord{
+		"a": {{UUID: "a", Authority: "Smartlogic"}},
+		"b": {{UUID: "b", Authority: "Smartlogic"}},
+	}
+	records, err := cached.GetConcordance(context.Background(), "a", "bookmark-2")
+	require.NoError(t, err)
+	assert.Equal(t, []ConcordanceRecord{{UUID: "a", Authority: "Smartlogic"}}, records)
+
+	records, err = cached.GetConcordance(context.Background(), "b", "")
+	require.NoError(t, err)
+	assert.Equal(t, []ConcordanceRecord{{UUID: "b", Authority: "Smartlogic"}}, records, "a member which left the cluster should not find it in the cache")
+	assert.Equal(t, []string{"bookmark-1", "bookmark-2", ""}, client.bookmarks)
+}
+
+func TestCachedClient_GetConcordance_ErrorsAreNotCached(t *testing.T) {
+	client := &clusterClient{err: errors.New("reader is down")}
+	cached := NewCachedClient(client, 10, time.Minute)
+
+	_, err := cached.GetConcordance(context.Background(), "a", "")
+	assert.Error(t, err)
+
+	client.err = nil
+	client.clusters = map[string][]ConcordanceRecord{"a": {{UUID: "a", Authority: "Smartlogic"}}}
+	records, err := cached.GetConcordance(context.Background(), "a", "")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Len(t, client.bookmarks, 2)
+}
+
+func TestCachedClient_Healthcheck(t *testing.T) {
+	cached := NewCachedClient(&clusterClient{}, 10, time.Minute)
+	assert.Equal(t, "cluster-client", cached.Healthcheck().ID)
+}
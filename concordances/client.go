@@ -13,6 +13,8 @@ import (
 
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
 	"github.com/Financial-Times/go-logger"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
 type Client interface {
@@ -23,9 +25,11 @@ type Client interface {
 type RWClient struct {
 	address    *url.URL
 	httpClient *http.Client
+	retries    RetryPolicy
+	breaker    *circuitBreaker
 }
 
-func NewClient(address string) (Client, error) {
+func NewClient(address string, retries RetryPolicy, breaker BreakerPolicy) (Client, error) {
 	parsedURL, err := url.Parse(address)
 	if err != nil {
 		return nil, err
@@ -35,39 +39,65 @@ func NewClient(address string) (Client, error) {
 		httpClient: &http.Client{
 			Timeout: time.Second * 5,
 		},
+		retries: retries,
+		breaker: newCircuitBreaker(breaker),
 	}, nil
 }
 
+// GetConcordance retries requests failing with a 5xx status or a network error according to the retry policy.
+// While the circuit breaker is open it fails straight away with an unavailable error.
 func (c *RWClient) GetConcordance(ctx context.Context, uuid string, bookmark string) ([]ConcordanceRecord, error) {
+	for attempt := 1; ; attempt++ {
+		if !c.breaker.allow() {
+			return nil, failure.Wrap(failure.Unavailable, ErrCircuitOpen)
+		}
+		cons, retryable, err := c.getConcordance(ctx, uuid, bookmark)
+		if ctx.Err() != nil {
+			// the caller gave up, which says nothing about the health of the reader
+			c.breaker.abandon()
+		} else {
+			c.breaker.record(!retryable)
+		}
+		if !retryable || attempt >= c.retries.MaxAttempts || ctx.Err() != nil {
+			return cons, err
+		}
+
+		delay := c.retries.backoff(attempt)
+		logger.WithError(err).WithField("UUID", uuid).Warnf("Retrying concordances request in %s after attempt %d", delay, attempt)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// getConcordance makes a single request for the concordance, reporting whether it failed in a way worth retrying.
+func (c *RWClient) getConcordance(ctx context.Context, uuid string, bookmark string) ([]ConcordanceRecord, bool, error) {
 	respBody, status, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/concordances/%s", uuid), nil, bookmark)
 	if err != nil {
 		logger.WithError(err).Error("Could not get concordances")
-		return nil, err
+		return nil, true, failure.FromTransport(err)
 	}
 
 	if status == http.StatusNotFound {
-		// No concordance found, so we'll create a fake record to return the solo concept.
-		logger.WithError(err).WithField("UUID", uuid).Debug("No matching record in db")
-		return []ConcordanceRecord{
-			{
-				UUID:      uuid,
-				Authority: "Smartlogic", //we have to provide a primary authority here, but it will be indifferent at a later point if this is Smartlogic or ManagedLocation
-			},
-		}, nil
+		// No concordance found, so the concept is on its own. Its authority is only known from the source concept.
+		logger.WithField("UUID", uuid).Debug("No matching record in db")
+		return []ConcordanceRecord{{UUID: uuid, Solo: true}}, false, nil
 
 	}
 
 	if status != http.StatusOK {
 		logger.WithError(err).WithField("status", status).Error("Could not get concordances, invalid status")
-		return nil, errors.New("invalid status response")
+		return nil, status >= http.StatusInternalServerError, failure.FromStatus(status, errors.New("invalid status response"))
 	}
 
 	var cons []ConcordanceRecord
 	if err := json.Unmarshal(respBody, &cons); err != nil {
-		return nil, err
+		return nil, false, failure.Wrap(failure.Permanent, err)
 	}
 
-	return cons, nil
+	return cons, false, nil
 }
 
 func (c *RWClient) Healthcheck() fthealth.Check {
@@ -78,7 +108,7 @@ func (c *RWClient) Healthcheck() fthealth.Check {
 		Severity:       3,
 		PanicGuide:     "https://runbooks.in.ft.com/aggregate-concept-transformer",
 		TechnicalSummary: "The concordances-rw-neo4j service is inaccessible.  Check that the address is correct and " +
-			"the service is up.",
+			"the service is up. While the circuit breaker is open, concordance requests fail without calling the service.",
 		Timeout: 10 * time.Second,
 		Checker: func() (string, error) {
 			ctx, cancel := context.WithCancel(context.Background())
@@ -92,6 +122,13 @@ func (c *RWClient) Healthcheck() fthealth.Check {
 				errMsg := "bad status from gtg for concordances-rw-neo4j"
 				return errMsg, errors.New(errMsg)
 			}
+			switch state, failures, openedAt := c.breaker.status(); state {
+			case breakerOpen:
+				errMsg := fmt.Sprintf("circuit breaker is open after %d consecutive failed concordance requests, failing fast since %s", failures, openedAt.Format(time.RFC3339))
+				return errMsg, errors.New(errMsg)
+			case breakerHalfOpen:
+				return "circuit breaker is half-open, trying a concordance request", nil
+			}
 			return "", nil
 		},
 	}
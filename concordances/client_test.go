@@ -2,12 +2,16 @@ package concordances
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/Financial-Times/go-logger"
 
 	"github.com/stretchr/testify/suite"
 	"gopkg.in/jarcoal/httpmock.v1"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
 func init() {
@@ -20,7 +24,7 @@ type RWTestSuite struct {
 }
 
 func (suite *RWTestSuite) SetupTest() {
-	client, err := NewClient("http://localhost")
+	client, err := NewClient("http://localhost", RetryPolicy{MaxAttempts: 1}, BreakerPolicy{})
 	suite.Nil(err)
 	suite.client = client.(*RWClient)
 }
@@ -49,6 +53,19 @@ func (suite *RWTestSuite) TestGetConcordance_FailOnStatus() {
 	cs, err := suite.client.GetConcordance(context.Background(), "a", "")
 	suite.Nil(cs)
 	suite.NotNil(err)
+	suite.Equal(failure.Transient, failure.KindOf(err))
+}
+
+func (suite *RWTestSuite) TestGetConcordance_UnavailableOnBadGateway() {
+	httpmock.RegisterResponder(
+		"GET",
+		"http://localhost/concordances/a",
+		httpmock.NewStringResponder(502, ``),
+	)
+
+	cs, err := suite.client.GetConcordance(context.Background(), "a", "")
+	suite.Nil(cs)
+	suite.Equal(failure.Unavailable, failure.KindOf(err))
 }
 
 func (suite *RWTestSuite) TestGetConcordance_FailOnInvalidJSON() {
@@ -61,6 +78,7 @@ func (suite *RWTestSuite) TestGetConcordance_FailOnInvalidJSON() {
 	cs, err := suite.client.GetConcordance(context.Background(), "a", "")
 	suite.Nil(cs)
 	suite.NotNil(err)
+	suite.Equal(failure.Permanent, failure.KindOf(err))
 }
 
 func (suite *RWTestSuite) TestGetConcordance_MissingConcordanceReturns404() {
@@ -72,8 +90,8 @@ func (suite *RWTestSuite) TestGetConcordance_MissingConcordanceReturns404() {
 
 	retCon := []ConcordanceRecord{
 		ConcordanceRecord{
-			UUID:      "a",
-			Authority: "Smartlogic",
+			UUID: "a",
+			Solo: true,
 		},
 	}
 
@@ -88,6 +106,116 @@ func (suite *RWTestSuite) TestGetConcordance_FailsOnClientError() {
 	suite.NotNil(err)
 }
 
+// sequenceResponder responds with the given statuses in turn, repeating the last one, and counts the requests.
+func sequenceResponder(calls *int, statuses ...int) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		status := statuses[len(statuses)-1]
+		if *calls < len(statuses) {
+			status = statuses[*calls]
+		}
+		*calls++
+		return httpmock.NewStringResponse(status, `[{"uuid": "a"}]`), nil
+	}
+}
+
+func (suite *RWTestSuite) TestGetConcordance_RetriesServerErrors() {
+	var calls int
+	httpmock.RegisterResponder("GET", "http://localhost/concordances/retried", sequenceResponder(&calls, 503, 500, 200))
+	suite.client.retries = RetryPolicy{MaxAttempts: 3}
+
+	cs, err := suite.client.GetConcordance(context.Background(), "retried", "")
+	suite.NoError(err)
+	suite.Len(cs, 1)
+	suite.Equal(3, calls)
+}
+
+func (suite *RWTestSuite) TestGetConcordance_GivesUpAfterMaxAttempts() {
+	var calls int
+	httpmock.RegisterResponder("GET", "http://localhost/concordances/failing", sequenceResponder(&calls, 502))
+	suite.client.retries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+	cs, err := suite.client.GetConcordance(context.Background(), "failing", "")
+	suite.Nil(cs)
+	suite.Equal(failure.Unavailable, failure.KindOf(err))
+	suite.Equal(3, calls)
+}
+
+func (suite *RWTestSuite) TestGetConcordance_RetriesNetworkErrors() {
+	var calls int
+	httpmock.RegisterResponder("GET", "http://localhost/concordances/unreachable", func(req *http.Request) (*http.Response, error) {
+		calls++
+		return httpmock.ConnectionFailure(req)
+	})
+	suite.client.retries = RetryPolicy{MaxAttempts: 2}
+
+	_, err := suite.client.GetConcordance(context.Background(), "unreachable", "")
+	suite.Error(err)
+	suite.Equal(2, calls)
+}
+
+func (suite *RWTestSuite) TestGetConcordance_DoesNotRetryClientErrors() {
+	for status, expectedErr := range map[int]bool{http.StatusBadRequest: true, http.StatusNotFound: false} {
+		var calls int
+		httpmock.RegisterResponder("GET", "http://localhost/concordances/client-error", sequenceResponder(&calls, status))
+		suite.client.retries = RetryPolicy{MaxAttempts: 3}
+
+		_, err := suite.client.GetConcordance(context.Background(), "client-error", "")
+		suite.Equal(expectedErr, err != nil, "status %d", status)
+		suite.Equal(1, calls, "status %d", status)
+	}
+}
+
+func (suite *RWTestSuite) TestGetConcordance_CircuitBreaker() {
+	var calls int
+	httpmock.RegisterResponder("GET", "http://localhost/concordances/breaker", sequenceResponder(&calls, 500, 500, 200))
+	httpmock.RegisterResponder("GET", "http://localhost/__gtg", httpmock.NewStringResponder(200, `{}`))
+	now := time.Now()
+	suite.client.breaker = newCircuitBreaker(BreakerPolicy{FailureThreshold: 2, OpenDuration: time.Minute})
+	suite.client.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.client.GetConcordance(context.Background(), "breaker", "")
+		suite.Equal(failure.Transient, failure.KindOf(err))
+	}
+
+	_, err := suite.client.GetConcordance(context.Background(), "breaker", "")
+	suite.ErrorIs(err, ErrCircuitOpen)
+	suite.Equal(failure.Unavailable, failure.KindOf(err))
+	suite.Equal(2, calls, "an open circuit breaker should not call the reader")
+
+	status, err := suite.client.Healthcheck().Checker()
+	suite.Error(err)
+	suite.Contains(status, "circuit breaker is open after 2 consecutive failed concordance requests")
+
+	now = now.Add(time.Minute)
+	status, err = suite.client.Healthcheck().Checker()
+	suite.NoError(err)
+	suite.Contains(status, "half-open")
+
+	cs, err := suite.client.GetConcordance(context.Background(), "breaker", "")
+	suite.NoError(err)
+	suite.Len(cs, 1)
+	state, _, _ := suite.client.breaker.status()
+	suite.Equal(breakerClosed, state)
+}
+
+func (suite *RWTestSuite) TestGetConcordance_CancelledRequestsDoNotOpenTheBreaker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	httpmock.RegisterResponder("GET", "http://localhost/concordances/cancelled", func(req *http.Request) (*http.Response, error) {
+		// the caller gives up while the reader is failing
+		cancel()
+		return httpmock.NewStringResponse(http.StatusServiceUnavailable, ""), nil
+	})
+	suite.client.breaker = newCircuitBreaker(BreakerPolicy{FailureThreshold: 1, OpenDuration: time.Minute})
+
+	_, err := suite.client.GetConcordance(ctx, "cancelled", "")
+	suite.Error(err)
+
+	state, failures, _ := suite.client.breaker.status()
+	suite.Equal(breakerClosed, state)
+	suite.Equal(0, failures)
+}
+
 func (suite *RWTestSuite) TestCheckHealth_Success() {
 	httpmock.RegisterResponder(
 		"GET",
@@ -1,7 +1,11 @@
 package concordances
 
+// ConcordanceRecord is a source concept of a concordance cluster.
+// Solo is set on the only record returned for a concept which is not concorded to anything.
+// The concordance reader does not know its authority, which is left empty for the caller to read from the source concept.
 type ConcordanceRecord struct {
 	UUID           string `json:"uuid"`
 	Authority      string `json:"authority"`
 	AuthorityValue string `json:"authorityValue"`
+	Solo           bool   `json:"solo,omitempty"`
 }
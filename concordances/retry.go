@@ -0,0 +1,138 @@
+package concordances
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the concordances reader while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker to concordances-rw-neo4j is open")
+
+// RetryPolicy tells how many times a concordance request failing with a 5xx status or a network error is made,
+// and how long to wait in between. The delays grow exponentially from BaseDelay up to MaxDelay, with full jitter.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// backoff returns how long to wait after the given failed attempt, counting from 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// BreakerPolicy tells after how many consecutive failed requests the circuit breaker opens, and for how long it stays open
+// before a single trial request is let through. The circuit breaker is disabled when FailureThreshold is 0.
+type BreakerPolicy struct {
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+type breakerState string
+
+const (
+	breakerClosed   breakerState = "closed"
+	breakerOpen     breakerState = "open"
+	breakerHalfOpen breakerState = "half-open"
+)
+
+// circuitBreaker stops requests to the concordances reader after too many consecutive failures, so they fail fast
+// instead of each waiting for its retries to time out.
+type circuitBreaker struct {
+	policy BreakerPolicy
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(policy BreakerPolicy) *circuitBreaker {
+	return &circuitBreaker{policy: policy, now: time.Now, state: breakerClosed}
+}
+
+// allow reports whether a request can be made. Once the breaker has been open for long enough,
+// it becomes half-open and allows a single trial request, whose outcome closes or opens it again.
+func (b *circuitBreaker) allow() bool {
+	if b.policy.FailureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen && b.now().Sub(b.openedAt) >= b.policy.OpenDuration {
+		b.state = breakerHalfOpen
+		b.trial = false
+	}
+	switch b.state {
+	case breakerOpen:
+		return false
+	case breakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record counts the outcome of a request made after allow.
+func (b *circuitBreaker) record(success bool) {
+	if b.policy.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.policy.FailureThreshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon forgets a request made after allow whose outcome is unknown, e.g. because its caller gave up on it,
+// so that a half-open breaker lets another trial request through.
+func (b *circuitBreaker) abandon() {
+	if b.policy.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.trial = false
+	}
+}
+
+// status returns the state of the breaker, the number of consecutive failures and when it last opened.
+// A breaker which has been open for long enough is reported as half-open, as the next request will be let through,
+// so that a health check gating the consumption of concept updates does not keep it open forever.
+func (b *circuitBreaker) status() (breakerState, int, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerOpen && b.now().Sub(b.openedAt) >= b.policy.OpenDuration {
+		return breakerHalfOpen, b.failures, b.openedAt
+	}
+	return b.state, b.failures, b.openedAt
+}
@@ -0,0 +1,78 @@
+package concordances
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	testCases := map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 300 * time.Millisecond,
+		6: 300 * time.Millisecond,
+	}
+	for attempt, maxDelay := range testCases {
+		for i := 0; i < 20; i++ {
+			delay := policy.backoff(attempt)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, maxDelay, "attempt %d", attempt)
+		}
+	}
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.backoff(1))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(BreakerPolicy{FailureThreshold: 2, OpenDuration: time.Second})
+	breaker.now = func() time.Time { return now }
+
+	assert.True(t, breaker.allow())
+	breaker.record(false)
+	assert.True(t, breaker.allow(), "the breaker should stay closed below the threshold")
+	breaker.record(false)
+	assert.False(t, breaker.allow(), "the breaker should open at the threshold")
+
+	now = now.Add(time.Second)
+	assert.True(t, breaker.allow(), "a trial request should be let through once the breaker has been open long enough")
+	assert.False(t, breaker.allow(), "only one trial request should be let through")
+	breaker.record(false)
+	assert.False(t, breaker.allow(), "a failed trial request should open the breaker again")
+
+	now = now.Add(time.Second)
+	assert.True(t, breaker.allow())
+	breaker.record(true)
+	state, failures, _ := breaker.status()
+	assert.Equal(t, breakerClosed, state)
+	assert.Equal(t, 0, failures)
+	assert.True(t, breaker.allow())
+}
+
+func TestCircuitBreaker_Abandon(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(BreakerPolicy{FailureThreshold: 1, OpenDuration: time.Second})
+	breaker.now = func() time.Time { return now }
+
+	assert.True(t, breaker.allow())
+	breaker.abandon()
+	state, failures, _ := breaker.status()
+	assert.Equal(t, breakerClosed, state, "an abandoned request should not count as a failure")
+	assert.Equal(t, 0, failures)
+
+	breaker.record(false)
+	now = now.Add(time.Second)
+	assert.True(t, breaker.allow())
+	breaker.abandon()
+	assert.True(t, breaker.allow(), "an abandoned trial request should let another one through")
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	breaker := newCircuitBreaker(BreakerPolicy{})
+	for i := 0; i < 10; i++ {
+		breaker.record(false)
+	}
+	assert.True(t, breaker.allow())
+}
@@ -0,0 +1,154 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Kind tells the caller how a failed concept update should be handled.
+type Kind int
+
+const (
+	// Transient failures are expected to succeed when the update is retried after a short delay.
+	Transient Kind = iota
+	// Permanent failures will keep failing no matter how many times the update is retried.
+	Permanent
+	// Unavailable failures mean a downstream service cannot be reached, so every update is likely to fail for a while.
+	Unavailable
+	// Validation failures mean the concept data itself is malformed.
+	Validation
+)
+
+var kindNames = map[Kind]string{
+	Transient:   "transient",
+	Permanent:   "permanent",
+	Unavailable: "unavailable",
+	Validation:  "validation",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Error attaches a Kind to an error. The message is left untouched so callers logging or comparing errors see no difference.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap classifies err as the given kind. A nil error stays nil and an error which is already classified keeps its kind.
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind of err. Errors which were never classified are treated as transient so they are retried.
+func KindOf(err error) Kind {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Kind
+	}
+	return Transient
+}
+
+// FromStatus classifies an error caused by a downstream service responding with the given HTTP status code.
+func FromStatus(status int, err error) error {
+	switch {
+	case status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return Wrap(Unavailable, err)
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		// the service is misconfigured rather than the concept being broken, so no update will get through
+		return Wrap(Unavailable, err)
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return Wrap(Validation, err)
+	case status == http.StatusTooManyRequests || status >= http.StatusInternalServerError:
+		return Wrap(Transient, err)
+	case status >= http.StatusBadRequest:
+		return Wrap(Permanent, err)
+	}
+	return Wrap(Transient, err)
+}
+
+// FromTransport classifies an error returned while making an HTTP request, before any response was received.
+func FromTransport(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return Wrap(Transient, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return Wrap(Transient, err)
+	}
+	return Wrap(Unavailable, err)
+}
+
+var throttleCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottledException":              true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"TransactionInProgressException":         true,
+	"RequestLimitExceeded":                   true,
+	"BandwidthLimitExceeded":                 true,
+	"LimitExceededException":                 true,
+	"RequestThrottled":                       true,
+	"SlowDown":                               true,
+	"PriorRequestNotComplete":                true,
+	"EC2ThrottledException":                  true,
+}
+
+// missingResourceCodes are returned when a stream, topic or bucket the service is configured with does not exist.
+var missingResourceCodes = map[string]bool{
+	"ResourceNotFoundException": true,
+	"NotFound":                  true,
+	"NoSuchBucket":              true,
+	"AWS.SimpleQueueService.NonExistentQueue": true,
+}
+
+// FromAWS classifies an error returned by the AWS SDK.
+func FromAWS(err error) error {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return FromTransport(err)
+	}
+	switch code := awsErr.Code(); {
+	case throttleCodes[code]:
+		return Wrap(Transient, err)
+	case missingResourceCodes[code]:
+		return Wrap(Unavailable, err)
+	case code == request.CanceledErrorCode:
+		return Wrap(Transient, err)
+	case code == request.ErrCodeRequestError:
+		return Wrap(Unavailable, err)
+	case code == request.ErrCodeSerialization:
+		return Wrap(Transient, err)
+	}
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) && reqErr.StatusCode() != 0 {
+		return FromStatus(reqErr.StatusCode(), err)
+	}
+	return Wrap(Transient, err)
+}
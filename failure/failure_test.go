@@ -0,0 +1,101 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	assert.NoError(t, Wrap(Permanent, nil))
+
+	err := Wrap(Permanent, errors.New("more than 1 primary authority"))
+	assert.EqualError(t, err, "more than 1 primary authority")
+	assert.Equal(t, Permanent, KindOf(err))
+
+	rewrapped := Wrap(Transient, fmt.Errorf("getting concordances: %w", err))
+	assert.Equal(t, Permanent, KindOf(rewrapped))
+}
+
+func TestKindOf_DefaultsToTransient(t *testing.T) {
+	assert.Equal(t, Transient, KindOf(errors.New("something went wrong")))
+	assert.Equal(t, Transient, KindOf(context.DeadlineExceeded))
+}
+
+func TestFromStatus(t *testing.T) {
+	testCases := map[int]Kind{
+		http.StatusBadRequest:          Validation,
+		http.StatusUnauthorized:        Unavailable,
+		http.StatusForbidden:           Unavailable,
+		http.StatusNotFound:            Permanent,
+		http.StatusConflict:            Permanent,
+		http.StatusUnprocessableEntity: Validation,
+		http.StatusTooManyRequests:     Transient,
+		http.StatusInternalServerError: Transient,
+		http.StatusBadGateway:          Unavailable,
+		http.StatusServiceUnavailable:  Unavailable,
+		http.StatusGatewayTimeout:      Unavailable,
+	}
+	for status, expected := range testCases {
+		err := FromStatus(status, errors.New("invalid status response"))
+		assert.Equal(t, expected, KindOf(err), "status %d", status)
+	}
+}
+
+func TestFromTransport(t *testing.T) {
+	assert.Equal(t, Unavailable, KindOf(FromTransport(errors.New("connection refused"))))
+	assert.Equal(t, Transient, KindOf(FromTransport(fmt.Errorf("calling writer: %w", context.DeadlineExceeded))))
+}
+
+func TestFromAWS(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected Kind
+	}{
+		"Throttled": {
+			err:      awserr.NewRequestFailure(awserr.New("ProvisionedThroughputExceededException", "Rate exceeded", nil), 400, "req-1"),
+			expected: Transient,
+		},
+		"Connection error": {
+			err:      awserr.New(request.ErrCodeRequestError, "send request failed", errors.New("connection refused")),
+			expected: Unavailable,
+		},
+		"Canceled": {
+			err:      awserr.New(request.CanceledErrorCode, "request context canceled", context.Canceled),
+			expected: Transient,
+		},
+		"Access denied": {
+			err:      awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), 403, "req-1"),
+			expected: Unavailable,
+		},
+		"Server error": {
+			err:      awserr.NewRequestFailure(awserr.New("InternalError", "We encountered an internal error", nil), 500, "req-1"),
+			expected: Transient,
+		},
+		"Stream not found": {
+			err:      awserr.NewRequestFailure(awserr.New("ResourceNotFoundException", "Stream not found", nil), 400, "req-1"),
+			expected: Unavailable,
+		},
+		"Client error": {
+			err:      awserr.NewRequestFailure(awserr.New("InvalidArgumentException", "Invalid partition key", nil), 400, "req-1"),
+			expected: Validation,
+		},
+		"Not an AWS error": {
+			err:      errors.New("connection reset by peer"),
+			expected: Unavailable,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := FromAWS(tc.err)
+			assert.Equal(t, tc.expected, KindOf(err))
+			assert.EqualError(t, err, tc.err.Error())
+		})
+	}
+}
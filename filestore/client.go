@@ -0,0 +1,142 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/Financial-Times/go-logger"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+// Scheme is the URL scheme of stores on the local filesystem.
+const Scheme = "file"
+
+const (
+	conceptSuffix  = ".json"
+	metadataSuffix = ".metadata.json"
+)
+
+// Client reads normalised concepts from a directory laid out like the S3 buckets, for running the service locally.
+// The concept with UUID 0a1b2c3d-... is read from 0a1b2c3d/....json, prefixed with the publication directory when there is one,
+// and its transaction ID from the Transaction_id field of the 0a1b2c3d/....metadata.json file next to it.
+type Client struct {
+	root string
+}
+
+// NewClient creates a client reading from the directory given as a file:// URL, e.g. file:///tmp/concepts.
+func NewClient(storeURL string) (*Client, error) {
+	u, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("unsupported store URL %s, expected a %s:// URL", storeURL, Scheme)
+	}
+	root := u.Path
+	if u.Host != "" {
+		// file://relative/path puts the first path element in the host
+		root = filepath.Join(u.Host, u.Path)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	return &Client{root: root}, nil
+}
+
+func (c *Client) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+	found, document, tid, err := c.GetConceptDocument(ctx, publication, UUID)
+	if err != nil || !found {
+		return found, ontology.SourceConcept{}, "", err
+	}
+
+	var concept ontology.SourceConcept
+	if err = json.Unmarshal(document, &concept); err != nil {
+		logger.WithError(err).WithUUID(UUID).Error("Cannot unmarshal file into a concept")
+		return true, ontology.SourceConcept{}, "", failure.Wrap(failure.Validation, err)
+	}
+	return true, concept, tid, nil
+}
+
+// GetConceptDocument reads the JSON document of a concept as it is stored, without decoding it.
+func (c *Client) GetConceptDocument(ctx context.Context, publication string, UUID string) (bool, []byte, string, error) {
+	if publication != "" && !filepath.IsLocal(publication) {
+		return false, nil, "", failure.Wrap(failure.Validation, fmt.Errorf("invalid publication %q", publication))
+	}
+	path := c.conceptPath(publication, UUID)
+
+	document, err := os.ReadFile(path + conceptSuffix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil, "", nil
+	}
+	if err != nil {
+		logger.WithError(err).WithUUID(UUID).Error("Error reading concept file")
+		return false, nil, "", err
+	}
+
+	tid, err := readTransactionID(path + metadataSuffix)
+	if err != nil {
+		logger.WithError(err).WithUUID(UUID).Error("Error reading concept metadata file")
+		return false, nil, "", failure.Wrap(failure.Validation, err)
+	}
+	return true, document, tid, nil
+}
+
+func (c *Client) Healthcheck() fthealth.Check {
+	return fthealth.Check{
+		BusinessImpact:   "Editorial updates of concepts will not be written into UPP",
+		Name:             "Check access to the local concept store",
+		PanicGuide:       "https://runbooks.in.ft.com/aggregate-concept-transformer",
+		Severity:         3,
+		TechnicalSummary: `Cannot read the local concept store directory. It is only meant to be used when running the service locally`,
+		Checker: func() (string, error) {
+			if _, err := os.ReadDir(c.root); err != nil {
+				logger.WithError(err).Error("Got error running local concept store health check")
+				return "", err
+			}
+			return "", nil
+		},
+	}
+}
+
+// ObjectKey returns the path of the file of a concept.
+func (c *Client) ObjectKey(publication string, UUID string) string {
+	return c.conceptPath(publication, UUID) + conceptSuffix
+}
+
+// conceptPath returns the path of the files of a concept, without their suffix.
+func (c *Client) conceptPath(publication string, UUID string) string {
+	return filepath.Join(c.root, publication, strings.Replace(UUID, "-", "/", -1))
+}
+
+// readTransactionID returns the transaction ID from a metadata file, or an empty string if there is no metadata file.
+func readTransactionID(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var metadata struct {
+		TransactionID string `json:"Transaction_id"`
+	}
+	if err = json.Unmarshal(b, &metadata); err != nil {
+		return "", err
+	}
+	return metadata.TransactionID, nil
+}
@@ -0,0 +1,141 @@
+package filestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestNewClient(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "file.json"), "{}")
+
+	client, err := NewClient("file://" + root)
+	assert.NoError(t, err)
+	assert.Equal(t, root, client.root)
+
+	_, err = NewClient("s3://" + root)
+	assert.EqualError(t, err, "unsupported store URL s3://"+root+", expected a file:// URL")
+
+	_, err = NewClient("file://" + filepath.Join(root, "missing"))
+	assert.Error(t, err)
+
+	_, err = NewClient("file://" + filepath.Join(root, "file.json"))
+	assert.EqualError(t, err, filepath.Join(root, "file.json")+" is not a directory")
+}
+
+func TestClient_GetConceptAndTransactionID(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed.json"), `{"uuid": "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", "prefLabel": "Internal"}`)
+	writeFile(t, filepath.Join(root, "b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed.metadata.json"), `{"Transaction_id": "tid_internal"}`)
+	writeFile(t, filepath.Join(root, "Generic/b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed.json"), `{"uuid": "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", "prefLabel": "External"}`)
+	writeFile(t, filepath.Join(root, "c9d3a92a/da84/11e7/a121/0401beb96201.json"), `{"uuid": `)
+	writeFile(t, filepath.Join(root, "3a3da730/0f4c/4a20/85a6/3ebd5776bd49.json"), `{"uuid": "3a3da730-0f4c-4a20-85a6-3ebd5776bd49"}`)
+	writeFile(t, filepath.Join(root, "3a3da730/0f4c/4a20/85a6/3ebd5776bd49.metadata.json"), `Transaction_id: tid`)
+	client := &Client{root: root}
+
+	testCases := map[string]struct {
+		publication   string
+		uuid          string
+		expectedFound bool
+		expectedLabel string
+		expectedTID   string
+		expectedErr   bool
+	}{
+		"Concept with metadata": {
+			uuid:          "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed",
+			expectedFound: true,
+			expectedLabel: "Internal",
+			expectedTID:   "tid_internal",
+		},
+		"Concept of a publication without metadata": {
+			publication:   "Generic",
+			uuid:          "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed",
+			expectedFound: true,
+			expectedLabel: "External",
+		},
+		"Missing concept": {
+			uuid: "99309d51-8969-4a1e-8346-d51f1981479b",
+		},
+		"Missing publication": {
+			publication: "d9a1b2c3-0000-4000-8000-000000000000",
+			uuid:        "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed",
+		},
+		"Invalid concept": {
+			uuid:          "c9d3a92a-da84-11e7-a121-0401beb96201",
+			expectedFound: true,
+			expectedErr:   true,
+		},
+		"Invalid metadata": {
+			uuid:        "3a3da730-0f4c-4a20-85a6-3ebd5776bd49",
+			expectedErr: true,
+		},
+		"Publication outside of the store": {
+			publication: "../Generic",
+			uuid:        "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed",
+			expectedErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			found, concept, tid, err := client.GetConceptAndTransactionID(context.Background(), tc.publication, tc.uuid)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				assert.Equal(t, failure.Validation, failure.KindOf(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedFound, found)
+			assert.Equal(t, tc.expectedLabel, concept.PrefLabel)
+			assert.Equal(t, tc.expectedTID, tid)
+		})
+	}
+}
+
+func TestClient_ObjectKey(t *testing.T) {
+	client := &Client{root: "/concepts"}
+	assert.Equal(t, filepath.Join("/concepts", "Generic", "f3633e04", "2ee3", "48ce", "8081", "37734dab3fdc.json"), client.ObjectKey("Generic", "f3633e04-2ee3-48ce-8081-37734dab3fdc"))
+}
+
+func TestClient_Healthcheck(t *testing.T) {
+	root := t.TempDir()
+	client := &Client{root: root}
+
+	_, err := client.Healthcheck().Checker()
+	assert.NoError(t, err)
+
+	require.NoError(t, os.Remove(root))
+	_, err = client.Healthcheck().Checker()
+	assert.Error(t, err)
+}
+
+func TestClient_GetConceptDocument(t *testing.T) {
+	root := t.TempDir()
+	document := `{"uuid": "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", "aliases": "Not a list"}`
+	writeFile(t, filepath.Join(root, "b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed.json"), document)
+	writeFile(t, filepath.Join(root, "b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed.metadata.json"), `{"Transaction_id": "tid_internal"}`)
+	client := &Client{root: root}
+
+	found, actual, tid, err := client.GetConceptDocument(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, document, string(actual))
+	assert.Equal(t, "tid_internal", tid)
+
+	found, _, _, err = client.GetConceptDocument(context.Background(), "", "99309d51-8969-4a1e-8346-d51f1981479b")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
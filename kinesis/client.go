@@ -10,6 +10,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kinesis"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
 type Client interface {
@@ -57,7 +59,7 @@ func (c *KinesisClient) AddRecordToStream(ctx context.Context, updatedConcept []
 	}
 
 	if _, err := c.svc.PutRecordWithContext(ctx, putRecordInput); err != nil {
-		return err
+		return failure.FromAWS(err)
 	}
 	return nil
 }
@@ -0,0 +1,75 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New[string, int](2, 0)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	cache.Add("c", 3)
+	assert.Equal(t, 2, cache.Len())
+
+	_, ok = cache.Get("b")
+	assert.False(t, ok, "b was the least recently used entry")
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	value, ok = cache.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+}
+
+func TestCache_AddReplacesValue(t *testing.T) {
+	cache := New[string, int](2, 0)
+	cache.Add("a", 1)
+	cache.Add("a", 2)
+
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestCache_ExpiresEntries(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := New[string, int](2, time.Minute)
+	cache.now = func() time.Time { return now }
+	cache.Add("a", 1)
+
+	now = now.Add(59 * time.Second)
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len(), "expired entries are evicted when read")
+}
+
+func TestCache_Remove(t *testing.T) {
+	cache := New[string, int](2, 0)
+	cache.Add("a", 1)
+	cache.Remove("a")
+	cache.Remove("b")
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCache_ZeroSizeStoresNothing(t *testing.T) {
+	cache := New[string, int](0, 0)
+	cache.Add("a", 1)
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/Financial-Times/aggregate-concept-transformer/concept"
 	"github.com/Financial-Times/aggregate-concept-transformer/concordances"
+	"github.com/Financial-Times/aggregate-concept-transformer/filestore"
 	"github.com/Financial-Times/aggregate-concept-transformer/kinesis"
 	"github.com/Financial-Times/aggregate-concept-transformer/s3"
 	"github.com/Financial-Times/aggregate-concept-transformer/sns"
@@ -70,6 +71,33 @@ func main() {
 		Value:  "eu-west-1",
 		EnvVar: "EXTERNAL_BUCKET_REGION",
 	})
+	publicationRoutes := app.String(cli.StringOpt{
+		Name:   "publicationRoutes",
+		Desc:   "Path to a JSON file routing publications to their own S3 bucket, region and prefix. Publications without a route are read from the external bucket",
+		EnvVar: "PUBLICATION_ROUTES",
+	})
+	normalisedStore := app.String(cli.StringOpt{
+		Name:   "normalisedStore",
+		Desc:   "Local directory to read concepts from instead of the S3 bucket, as a file:// URL (for local development only)",
+		EnvVar: "NORMALISED_STORE",
+	})
+	externalNormalisedStore := app.String(cli.StringOpt{
+		Name:   "externalNormalisedStore",
+		Desc:   "Local directory to read external concepts from instead of the external S3 bucket, as a file:// URL (for local development only)",
+		EnvVar: "EXTERNAL_NORMALISED_STORE",
+	})
+	s3CacheSize := app.Int(cli.IntOpt{
+		Name:   "s3CacheSize",
+		Value:  0,
+		Desc:   "Number of source concepts from each S3 bucket kept in memory and only downloaded again once they change. The cache is disabled when 0",
+		EnvVar: "S3_CACHE_SIZE",
+	})
+	s3CacheTTL := app.Int(cli.IntOpt{
+		Name:   "s3CacheTTL",
+		Value:  3600,
+		Desc:   "Duration(seconds) that a source concept is kept in the S3 cache",
+		EnvVar: "S3_CACHE_TTL",
+	})
 	conceptUpdatesQueueURL := app.String(cli.StringOpt{
 		Name:   "conceptUpdatesQueueURL",
 		Desc:   "Url of AWS SQS queue to listen for concept updates",
@@ -80,6 +108,17 @@ func main() {
 		Desc:   "AWS Region in which the SQS queue is located",
 		EnvVar: "SQS_REGION",
 	})
+	deadLetterQueueURL := app.String(cli.StringOpt{
+		Name:   "deadLetterQueueURL",
+		Desc:   "Url of AWS SQS queue that concept updates are moved to once they exceed the maximum receive count",
+		EnvVar: "DEAD_LETTER_QUEUE_URL",
+	})
+	maxReceiveCount := app.Int(cli.IntOpt{
+		Name:   "maxReceiveCount",
+		Value:  5,
+		Desc:   "Number of times a concept update can fail before it is moved to the dead-letter queue",
+		EnvVar: "MAX_RECEIVE_COUNT",
+	})
 	sqsEndpoint := app.String(cli.StringOpt{
 		Name:   "sqsEndpoint",
 		Desc:   "SQS queue endpoint (for local debugging only)",
@@ -88,13 +127,43 @@ func main() {
 	messagesToProcess := app.Int(cli.IntOpt{
 		Name:   "messagesToProcess",
 		Value:  10,
-		Desc:   "Maximum number or messages to concurrently read off of queue and process",
+		Desc:   "Maximum number of messages to read off of queue in a single request (at most 10)",
 		EnvVar: "MAX_MESSAGES",
 	})
+	receivers := app.Int(cli.IntOpt{
+		Name:   "receivers",
+		Value:  2,
+		Desc:   "Number of concurrent requests reading messages off of queue",
+		EnvVar: "RECEIVERS",
+	})
+	processors := app.Int(cli.IntOpt{
+		Name:   "processors",
+		Value:  runtime.GOMAXPROCS(0) + 1,
+		Desc:   "Number of messages processed concurrently",
+		EnvVar: "PROCESSORS",
+	})
+	maxInFlight := app.Int(cli.IntOpt{
+		Name:   "maxInFlight",
+		Value:  50,
+		Desc:   "Maximum number of messages read off of queue and not yet processed, including those being processed",
+		EnvVar: "MAX_IN_FLIGHT",
+	})
+	sourceFetchConcurrency := app.Int(cli.IntOpt{
+		Name:   "sourceFetchConcurrency",
+		Value:  8,
+		Desc:   "Maximum number of source concepts of a single concorded concept fetched from S3 concurrently",
+		EnvVar: "SOURCE_FETCH_CONCURRENCY",
+	})
+	sourceValidation := app.String(cli.StringOpt{
+		Name:   "sourceValidation",
+		Value:  string(concept.ValidationExclude),
+		Desc:   "What to do with source concepts which do not match their JSON schema: 'exclude' aggregates them as if they were not found, 'reject' fails the aggregation and 'off' does not validate them",
+		EnvVar: "SOURCE_VALIDATION",
+	})
 	visibilityTimeout := app.Int(cli.IntOpt{
 		Name:   "visibilityTimeout",
 		Value:  30,
-		Desc:   "Duration(seconds) that messages will be ignored by subsequent requests after initial response",
+		Desc:   "Duration(seconds) that messages will be ignored by subsequent requests after initial response. Extended for as long as the message is being processed",
 		EnvVar: "VISIBILITY_TIMEOUT",
 	})
 	httpTimeout := app.Int(cli.IntOpt{
@@ -121,6 +190,48 @@ func main() {
 		Desc:   "Address for the Neo4J Concept Writer",
 		EnvVar: "CONCORDANCES_RW_ADDRESS",
 	})
+	concordancesMaxAttempts := app.Int(cli.IntOpt{
+		Name:   "concordancesMaxAttempts",
+		Value:  3,
+		Desc:   "Number of times a concordances request failing with a 5xx status or a network error is made before giving up",
+		EnvVar: "CONCORDANCES_MAX_ATTEMPTS",
+	})
+	concordancesRetryDelay := app.Int(cli.IntOpt{
+		Name:   "concordancesRetryDelay",
+		Value:  100,
+		Desc:   "Duration(milliseconds) of the first delay before retrying a concordances request. It doubles with every attempt, and a random part of it is waited",
+		EnvVar: "CONCORDANCES_RETRY_DELAY",
+	})
+	concordancesMaxRetryDelay := app.Int(cli.IntOpt{
+		Name:   "concordancesMaxRetryDelay",
+		Value:  2000,
+		Desc:   "Duration(milliseconds) of the longest delay before retrying a concordances request",
+		EnvVar: "CONCORDANCES_MAX_RETRY_DELAY",
+	})
+	concordancesBreakerThreshold := app.Int(cli.IntOpt{
+		Name:   "concordancesBreakerThreshold",
+		Value:  5,
+		Desc:   "Number of consecutive failed concordances requests after which they fail fast without calling the reader. The circuit breaker is disabled when 0",
+		EnvVar: "CONCORDANCES_BREAKER_THRESHOLD",
+	})
+	concordancesBreakerOpenDuration := app.Int(cli.IntOpt{
+		Name:   "concordancesBreakerOpenDuration",
+		Value:  30,
+		Desc:   "Duration(seconds) that concordances requests fail fast once the circuit breaker opens, before a trial request is made",
+		EnvVar: "CONCORDANCES_BREAKER_OPEN_DURATION",
+	})
+	concordancesCacheSize := app.Int(cli.IntOpt{
+		Name:   "concordancesCacheSize",
+		Value:  0,
+		Desc:   "Number of concept UUIDs whose concordance cluster is kept in memory. Requests with a new bookmark always read the cluster again. The cache is disabled when 0",
+		EnvVar: "CONCORDANCES_CACHE_SIZE",
+	})
+	concordancesCacheTTL := app.Int(cli.IntOpt{
+		Name:   "concordancesCacheTTL",
+		Value:  300,
+		Desc:   "Duration(seconds) that a concordance cluster is kept in the concordances cache",
+		EnvVar: "CONCORDANCES_CACHE_TTL",
+	})
 	elasticsearchWriterAddress := app.String(cli.StringOpt{
 		Name:   "elasticsearchWriterAddress",
 		Value:  "http://localhost:8083/",
@@ -133,12 +244,53 @@ func main() {
 		Desc:   "Address for the Varnish Purger application",
 		EnvVar: "VARNISH_PURGER_ADDRESS",
 	})
+	contentHashCacheSize := app.Int(cli.IntOpt{
+		Name:   "contentHashCacheSize",
+		Value:  0,
+		Desc:   "Number of concepts whose content hash is kept in memory, so that an update which would not change a concept skips the writers. The skip trusts this hash over Neo4j, which is not read before skipping. The memory is not shared between replicas, so only use it with a single replica. Every update is written when 0 and there is no content hash directory",
+		EnvVar: "CONTENT_HASH_CACHE_SIZE",
+	})
+	contentHashDir := app.String(cli.StringOpt{
+		Name:   "contentHashDir",
+		Value:  "",
+		Desc:   "Directory the content hashes of the concepts written are kept in, so that they survive restarts. It must be a volume shared by every replica, otherwise only use it with a single replica. Content hashes are kept in memory when not set",
+		EnvVar: "CONTENT_HASH_DIR",
+	})
 	typesToPurgeFromPublicEndpoints := app.Strings(cli.StringsOpt{
 		Name:   "typesToPurgeFromPublicEndpoints",
 		Value:  []string{"Person", "Brand", "Organisation", "PublicCompany"},
 		Desc:   "Concept types that need purging from specific public endpoints (other than /things)",
 		EnvVar: "TYPES_TO_PURGE_FROM_PUBLIC_ENDPOINTS",
 	})
+	purgeRules := app.String(cli.StringOpt{
+		Name:   "purgeRules",
+		Value:  "",
+		Desc:   "Path to a JSON file listing the related concepts purged from the cache along with an updated concept of each type. FinancialInstruments purge their issuedBy Organisation and Memberships their HAS_MEMBER Person when not set",
+		EnvVar: "PURGE_RULES",
+	})
+	elasticsearchPolicy := app.String(cli.StringOpt{
+		Name:   "elasticsearchPolicy",
+		Value:  "",
+		Desc:   "Path to a JSON file allowing or denying sending concepts of each type to Elasticsearch. FinancialInstruments, roles, non-Smartlogic Memberships and industry classifications are not sent when not set",
+		EnvVar: "ELASTICSEARCH_POLICY",
+	})
+	elasticsearchPolicyReloadInterval := app.Int(cli.IntOpt{
+		Name:   "elasticsearchPolicyReloadInterval",
+		Value:  60,
+		Desc:   "How often in seconds the Elasticsearch policy file is checked for changes, which are then loaded. The file is only read at start up and on POST /__elasticsearch-policy when 0",
+		EnvVar: "ELASTICSEARCH_POLICY_RELOAD_INTERVAL",
+	})
+	authorityPrecedence := app.Strings(cli.StringsOpt{
+		Name:   "authorityPrecedence",
+		Value:  []string{"Smartlogic", "ManagedLocation", "FACTSET", "TME"},
+		Desc:   "Authorities in order of precedence, used to order source concepts and to choose the primary concept when there is no primary authority",
+		EnvVar: "AUTHORITY_PRECEDENCE",
+	})
+	primaryAuthorityRules := app.String(cli.StringOpt{
+		Name:   "primaryAuthorityRules",
+		Desc:   "Path to a JSON file listing the primary authorities in order of importance and how to handle concepts concorded to more than one record of each. Smartlogic and then ManagedLocation, both failing on multiple records, when not set",
+		EnvVar: "PRIMARY_AUTHORITY_RULES",
+	})
 	crossAccountRoleARN := app.String(cli.StringOpt{
 		Name:      "crossAccountRoleARN",
 		HideValue: true,
@@ -185,30 +337,68 @@ func main() {
 		logger.InitLogger(*appSystemCode, *logLevel)
 
 		logger.WithFields(log.Fields{
-			"ES_WRITER_ADDRESS":       *elasticsearchWriterAddress,
-			"CONCORDANCES_RW_ADDRESS": *concordancesReaderAddress,
-			"NEO_WRITER_ADDRESS":      *neoWriterAddress,
-			"VARNISH_PURGER_ADDRESS":  *varnishPurgerAddress,
-			"EXTERNAL_BUCKET_REGION":  *externalBucketRegion,
-			"EXTERNAL_BUCKET_NAME":    *externalBucketName,
-			"BUCKET_REGION":           *bucketRegion,
-			"BUCKET_NAME":             *bucketName,
-			"SQS_REGION":              *sqsRegion,
-			"CONCEPTS_QUEUE_URL":      *conceptUpdatesQueueURL,
-			"LOG_LEVEL":               *logLevel,
-			"KINESIS_STREAM_NAME":     *kinesisStreamName,
-			"CONCEPT_UPDATES_SNS_ARN": *conceptUpdatesSNSTopicArn,
+			"ES_WRITER_ADDRESS":              *elasticsearchWriterAddress,
+			"CONCORDANCES_RW_ADDRESS":        *concordancesReaderAddress,
+			"NEO_WRITER_ADDRESS":             *neoWriterAddress,
+			"VARNISH_PURGER_ADDRESS":         *varnishPurgerAddress,
+			"EXTERNAL_BUCKET_REGION":         *externalBucketRegion,
+			"EXTERNAL_BUCKET_NAME":           *externalBucketName,
+			"BUCKET_REGION":                  *bucketRegion,
+			"BUCKET_NAME":                    *bucketName,
+			"NORMALISED_STORE":               *normalisedStore,
+			"EXTERNAL_NORMALISED_STORE":      *externalNormalisedStore,
+			"PUBLICATION_ROUTES":             *publicationRoutes,
+			"SQS_REGION":                     *sqsRegion,
+			"CONCEPTS_QUEUE_URL":             *conceptUpdatesQueueURL,
+			"DEAD_LETTER_QUEUE_URL":          *deadLetterQueueURL,
+			"LOG_LEVEL":                      *logLevel,
+			"KINESIS_STREAM_NAME":            *kinesisStreamName,
+			"CONCEPT_UPDATES_SNS_ARN":        *conceptUpdatesSNSTopicArn,
+			"PRIMARY_AUTHORITY_RULES":        *primaryAuthorityRules,
+			"PURGE_RULES":                    *purgeRules,
+			"ELASTICSEARCH_POLICY":           *elasticsearchPolicy,
+			"SOURCE_VALIDATION":              *sourceValidation,
+			"CONCORDANCES_MAX_ATTEMPTS":      *concordancesMaxAttempts,
+			"CONCORDANCES_BREAKER_THRESHOLD": *concordancesBreakerThreshold,
+			"CONTENT_HASH_CACHE_SIZE":        *contentHashCacheSize,
+			"CONTENT_HASH_DIR":               *contentHashDir,
 		}).Info("Starting app with arguments")
 
-		if *bucketName == "" {
-			logger.Fatal("S3 bucket name not set")
-		}
-		if *bucketRegion == "" {
-			logger.Fatal("AWS bucket region not set")
+		if *normalisedStore == "" {
+			if *bucketName == "" {
+				logger.Fatal("S3 bucket name not set")
+			}
+			if *bucketRegion == "" {
+				logger.Fatal("AWS bucket region not set")
+			}
 		}
 		if *concordancesReaderAddress == "" {
 			logger.Fatal("Concordances reader address not set")
 		}
+		if *concordancesMaxAttempts < 1 {
+			logger.Fatal("Concordances max attempts must be at least 1")
+		}
+		if *concordancesRetryDelay < 0 || *concordancesMaxRetryDelay < *concordancesRetryDelay {
+			logger.Fatal("Concordances retry delays must not be negative, and the max retry delay must not be shorter than the first one")
+		}
+		if *concordancesBreakerThreshold < 0 {
+			logger.Fatal("Concordances breaker threshold must not be negative")
+		}
+		if *messagesToProcess < 1 || *messagesToProcess > sqs.MaxNumberOfMessages {
+			logger.Fatalf("Messages to process must be between 1 and %d, the most SQS returns from a single receive", sqs.MaxNumberOfMessages)
+		}
+		if *sourceFetchConcurrency < 1 {
+			logger.Fatal("Source fetch concurrency must be at least 1")
+		}
+		if _, err := concept.ParseValidationMode(*sourceValidation); err != nil {
+			logger.WithError(err).Fatal("Invalid source validation mode")
+		}
+		if *contentHashCacheSize < 0 {
+			logger.Fatal("Content hash cache size must not be negative")
+		}
+		if *elasticsearchPolicyReloadInterval < 0 {
+			logger.Fatal("Elasticsearch policy reload interval must not be negative")
+		}
 
 		if !*isReadOnly {
 			if *conceptUpdatesQueueURL == "" {
@@ -222,31 +412,78 @@ func main() {
 			if *kinesisStreamName == "" {
 				logger.Fatal("Kinesis stream name not set")
 			}
+
+			if *receivers < 1 || *processors < 1 || *maxInFlight < 1 {
+				logger.Fatal("Receivers, processors and max in flight messages must be at least 1")
+			}
 		}
 	}
 
 	app.Action = func() {
-		s3Client, err := s3.NewClient(*bucketName, *bucketRegion)
+		cacheTTL := time.Second * time.Duration(*s3CacheTTL)
+		s3Store, err := newConceptStore(*normalisedStore, *bucketName, *bucketRegion, *s3CacheSize, cacheTTL)
 		if err != nil {
-			logger.WithError(err).Fatal("Error creating S3 client for concept-normalised-store")
+			logger.WithError(err).Fatal("Error creating client for concept-normalised-store")
 		}
 
-		externalS3Client, err := s3.NewClient(*externalBucketName, *externalBucketRegion)
+		externalS3Store, err := newConceptStore(*externalNormalisedStore, *externalBucketName, *externalBucketRegion, *s3CacheSize, cacheTTL)
 		if err != nil {
-			logger.WithError(err).Fatal("Error creating S3 client for external-concept-normalised-store")
+			logger.WithError(err).Fatal("Error creating client for external-concept-normalised-store")
 		}
 
-		concordancesClient, err := concordances.NewClient(*concordancesReaderAddress)
+		routes, err := concept.LoadPublicationRoutes(*publicationRoutes)
+		if err != nil {
+			logger.WithError(err).Fatal("Error loading publication routes")
+		}
+		var publicationStores []concept.PublicationStore
+		for _, route := range routes {
+			store, err := newConceptStore("", route.Bucket, route.Region, *s3CacheSize, cacheTTL)
+			if err != nil {
+				logger.WithError(err).WithField("publication", route.Publication).Fatal("Error creating client for publication bucket")
+			}
+			publicationStores = append(publicationStores, concept.PublicationStore{Route: route, Store: store})
+		}
+
+		concordancesClient, err := concordances.NewClient(
+			*concordancesReaderAddress,
+			concordances.RetryPolicy{
+				MaxAttempts: *concordancesMaxAttempts,
+				BaseDelay:   time.Duration(*concordancesRetryDelay) * time.Millisecond,
+				MaxDelay:    time.Duration(*concordancesMaxRetryDelay) * time.Millisecond,
+			},
+			concordances.BreakerPolicy{
+				FailureThreshold: *concordancesBreakerThreshold,
+				OpenDuration:     time.Duration(*concordancesBreakerOpenDuration) * time.Second,
+			},
+		)
 		if err != nil {
 			logger.WithError(err).Fatal("Error creating Concordances client")
 		}
+		if *concordancesCacheSize > 0 {
+			concordancesClient = concordances.NewCachedClient(concordancesClient, *concordancesCacheSize, time.Second*time.Duration(*concordancesCacheTTL))
+		}
+
+		primaryRules, err := concept.LoadPrimaryAuthorityRules(*primaryAuthorityRules)
+		if err != nil {
+			logger.WithError(err).Fatal("Error loading primary authority rules")
+		}
+
+		relatedPurgeRules, err := concept.LoadPurgeRules(*purgeRules)
+		if err != nil {
+			logger.WithError(err).Fatal("Error loading purge rules")
+		}
+
+		elasticsearchPolicySource, err := concept.NewElasticsearchPolicySource(*elasticsearchPolicy)
+		if err != nil {
+			logger.WithError(err).Fatal("Error loading Elasticsearch policy")
+		}
 
 		var conceptUpdatesSqsClient sqs.Client
 		var eventsSNS sns.Client
 		var kinesisClient kinesis.Client
 
 		if !*isReadOnly {
-			conceptUpdatesSqsClient, err = sqs.NewClient(*sqsRegion, *conceptUpdatesQueueURL, *sqsEndpoint, *messagesToProcess, *visibilityTimeout, *waitTime)
+			conceptUpdatesSqsClient, err = sqs.NewClient(*sqsRegion, *conceptUpdatesQueueURL, *deadLetterQueueURL, *sqsEndpoint, *messagesToProcess, *visibilityTimeout, *waitTime, *maxReceiveCount)
 			if err != nil {
 				logger.WithError(err).Fatal("Error creating concept updates SQS client")
 			}
@@ -262,17 +499,23 @@ func main() {
 			}
 		}
 
+		var contentHashes concept.ContentHashStore
+		if *contentHashDir != "" {
+			if contentHashes, err = concept.NewFileHashStore(*contentHashDir); err != nil {
+				logger.WithError(err).Fatal("Error creating content hash directory")
+			}
+		} else if *contentHashCacheSize > 0 {
+			contentHashes = concept.NewMemoryHashStore(*contentHashCacheSize)
+		}
+
 		feedback := make(chan bool)
 		done := make(chan struct{})
 
-		maxWorkers := runtime.GOMAXPROCS(0) + 1
-		if *isReadOnly {
-			maxWorkers = 0
-		}
 		requestTimeout := time.Second * time.Duration(*httpTimeout)
 		svc := concept.NewService(
-			s3Client,
-			externalS3Client,
+			s3Store,
+			externalS3Store,
+			publicationStores,
 			conceptUpdatesSqsClient,
 			eventsSNS,
 			concordancesClient,
@@ -281,30 +524,38 @@ func main() {
 			*elasticsearchWriterAddress,
 			*varnishPurgerAddress,
 			*typesToPurgeFromPublicEndpoints,
-			defaultHTTPClient(maxWorkers),
+			relatedPurgeRules,
+			elasticsearchPolicySource,
+			*authorityPrecedence,
+			primaryRules,
+			concept.DefaultCanonicalValidators(),
+			defaultHTTPClient(*processors),
 			feedback,
 			done,
 			requestTimeout,
-			*isReadOnly)
+			time.Second*time.Duration(*visibilityTimeout),
+			*sourceFetchConcurrency,
+			concept.ValidationMode(*sourceValidation),
+			*isReadOnly,
+			contentHashes)
 
 		handler := concept.NewHandler(svc, requestTimeout)
 		hs := concept.NewHealthService(svc, *appSystemCode, *appName, *port, appDescription)
 
 		serveMux := handler.RegisterHandlers(hs, *requestLoggingOn, feedback)
 
-		logger.Infof("Running %d ListenForNotifications", maxWorkers)
 		var listenForNotificationsWG sync.WaitGroup
-		listenForNotificationsWG.Add(maxWorkers)
+		listenForNotificationsWG.Add(1)
 
 		workerCtx, workerCancel := context.WithCancel(context.Background())
 
-		for i := 0; i < maxWorkers; i++ {
-			go func(workerId int) {
-				logger.Infof("Starting ListenForNotifications worker %d", workerId)
-				svc.ListenForNotifications(workerCtx, workerId)
-				listenForNotificationsWG.Done()
-			}(i)
-		}
+		go elasticsearchPolicySource.Watch(workerCtx, time.Second*time.Duration(*elasticsearchPolicyReloadInterval))
+
+		go func() {
+			logger.Infof("Starting ListenForNotifications with %d receivers, %d processors and at most %d messages in flight", *receivers, *processors, *maxInFlight)
+			svc.ListenForNotifications(workerCtx, *receivers, *processors, *maxInFlight)
+			listenForNotificationsWG.Done()
+		}()
 
 		logger.Infof("Listening on port %v", *port)
 		srv := &http.Server{
@@ -333,8 +584,8 @@ func main() {
 		<-c
 		logger.Info("Interruption signal received, shutting down")
 		// Send done signal to service
-		workerCancel()
 		done <- struct{}{}
+		workerCancel()
 		logger.Info("Waiting for workers to stop")
 		listenForNotificationsWG.Wait()
 		// Create a deadline to wait for.
@@ -351,6 +602,21 @@ func main() {
 	app.Run(os.Args)
 }
 
+// newConceptStore reads concepts from the local directory when storeURL is set, or from the S3 bucket otherwise.
+func newConceptStore(storeURL string, bucketName string, bucketRegion string, cacheSize int, cacheTTL time.Duration) (s3.ConceptStore, error) {
+	if storeURL != "" {
+		return filestore.NewClient(storeURL)
+	}
+	client, err := s3.NewClient(bucketName, bucketRegion)
+	if err != nil {
+		return nil, err
+	}
+	if cacheSize > 0 {
+		return s3.NewCachedClient(client, cacheSize, cacheTTL), nil
+	}
+	return client, nil
+}
+
 func defaultHTTPClient(maxWorkers int) *http.Client {
 	return &http.Client{
 		Transport: &http.Transport{
@@ -58,7 +58,7 @@ func TestAggregateService_GetConceptHandler(t *testing.T) {
 	sqsClient := &sqsMock{}
 	snsClient := &snsMock{}
 	ksClient := &kinesisMock{}
-	concordancesClient, err := concordances.NewClient(server.URL)
+	concordancesClient, err := concordances.NewClient(server.URL, concordances.RetryPolicy{MaxAttempts: 1}, concordances.BreakerPolicy{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -69,7 +69,7 @@ func TestAggregateService_GetConceptHandler(t *testing.T) {
 	defer close(feedback)
 	defer close(done)
 
-	service := concept.NewService(s3, externalS3Mock, sqsClient, snsClient, concordancesClient, ksClient, server.URL+"/neo4j", server.URL+"/elastic", server.URL+"/varnish", []string{""}, server.Client(), feedback, done, timeout, true)
+	service := concept.NewService(s3, externalS3Mock, nil, sqsClient, snsClient, concordancesClient, ksClient, server.URL+"/neo4j", server.URL+"/elastic", server.URL+"/varnish", []string{""}, concept.DefaultPurgeRules(), nil, nil, concept.DefaultPrimaryAuthorityRules(), concept.DefaultCanonicalValidators(), server.Client(), feedback, done, timeout, timeout, 1, concept.ValidationReject, true, nil)
 	handler := concept.NewHandler(service, timeout)
 
 	m := handler.RegisterHandlers(concept.NewHealthService(service, "", "", 8080, ""), false, feedback)
@@ -164,7 +164,7 @@ func (s s3Mock) Healthcheck() fthealth.Check {
 type sqsMock struct {
 }
 
-func (s sqsMock) ListenAndServeQueue(ctx context.Context) []sqs.ConceptUpdate {
+func (s sqsMock) ListenAndServeQueue(ctx context.Context, maxMessages int) []sqs.ConceptUpdate {
 	//TODO implement me
 	panic("implement me")
 }
@@ -174,6 +174,21 @@ func (s sqsMock) RemoveMessageFromQueue(ctx context.Context, receiptHandle *stri
 	panic("implement me")
 }
 
+func (s sqsMock) DeadLetterMessage(ctx context.Context, update sqs.ConceptUpdate, transactionID string, reason string) (bool, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s sqsMock) QuarantineMessage(ctx context.Context, update sqs.ConceptUpdate, transactionID string, reason string) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (s sqsMock) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int) error {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (s sqsMock) Healthcheck() fthealth.Check {
 	return fthealth.Check{}
 }
@@ -197,3 +212,22 @@ func (k kinesisMock) AddRecordToStream(ctx context.Context, updatedConcept []byt
 func (k kinesisMock) Healthcheck() fthealth.Check {
 	return fthealth.Check{}
 }
+
+// the configuration deployed by the Helm chart keeps the behaviour of the defaults, until it is changed on purpose
+func TestHelmConfigMatchesDefaults(t *testing.T) {
+	purgeRules, err := concept.LoadPurgeRules("helm/aggregate-concept-transformer/config/purge-rules.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(concept.DefaultPurgeRules(), purgeRules) {
+		t.Error(cmp.Diff(concept.DefaultPurgeRules(), purgeRules))
+	}
+
+	policy, err := concept.LoadElasticsearchPolicy("helm/aggregate-concept-transformer/config/elasticsearch-policy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(concept.DefaultElasticsearchPolicy(), policy) {
+		t.Error(cmp.Diff(concept.DefaultElasticsearchPolicy(), policy))
+	}
+}
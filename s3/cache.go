@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/rcrowley/go-metrics"
+
+	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/lru"
+)
+
+// errNotModified is returned by a conditional read when the S3 object still has the ETag it was read with.
+var errNotModified = errors.New("concept not modified")
+
+type cachedConcept struct {
+	document []byte
+	metadata ObjectMetadata
+}
+
+// CachedClient keeps the most recently read concepts in memory.
+// A cached concept is only returned once a conditional GetObject has confirmed it has not changed in S3,
+// which saves downloading it again but still takes one request per read.
+// Concepts are decoded again from the cached document on every read, so concurrent aggregations of the same source
+// never share the slices and maps of a concept.
+type CachedClient struct {
+	client *Client
+	cache  *lru.Cache[string, cachedConcept]
+	hits   metrics.Counter
+	misses metrics.Counter
+}
+
+// NewCachedClient caches up to size concepts read through client, each for at most ttl.
+// Cache hits and misses are counted in the default metrics registry.
+func NewCachedClient(client *Client, size int, ttl time.Duration) *CachedClient {
+	return &CachedClient{
+		client: client,
+		cache:  lru.New[string, cachedConcept](size, ttl),
+		hits:   metrics.GetOrRegisterCounter(fmt.Sprintf("s3.%s.cache.hits", client.bucketName), metrics.DefaultRegistry),
+		misses: metrics.GetOrRegisterCounter(fmt.Sprintf("s3.%s.cache.misses", client.bucketName), metrics.DefaultRegistry),
+	}
+}
+
+func (c *CachedClient) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
+	found, cached, err := c.get(ctx, publication, UUID)
+	if err != nil || !found {
+		return found, ontology.SourceConcept{}, "", err
+	}
+	concept, err := decodeConcept(UUID, cached.document)
+	if err != nil {
+		return true, ontology.SourceConcept{}, "", err
+	}
+	return true, concept, cached.metadata.TransactionID, nil
+}
+
+// GetConceptAndTransactionIDAsOf reads a concept as it was at the given time. Old versions are not cached.
+func (c *CachedClient) GetConceptAndTransactionIDAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, ontology.SourceConcept, string, error) {
+	return c.client.GetConceptAndTransactionIDAsOf(ctx, publication, UUID, asOf)
+}
+
+// GetConceptDocument reads the JSON document of a concept as it is stored, without decoding it.
+func (c *CachedClient) GetConceptDocument(ctx context.Context, publication string, UUID string) (bool, []byte, string, error) {
+	found, cached, err := c.get(ctx, publication, UUID)
+	if err != nil || !found {
+		return found, nil, "", err
+	}
+	return true, bytes.Clone(cached.document), cached.metadata.TransactionID, nil
+}
+
+// GetConceptDocumentAsOf reads the JSON document of a concept as it was at the given time. Old versions are not cached.
+func (c *CachedClient) GetConceptDocumentAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, []byte, string, error) {
+	return c.client.GetConceptDocumentAsOf(ctx, publication, UUID, asOf)
+}
+
+// get reads a concept, downloading it only if its S3 object has changed since it was cached.
+// Documents which cannot be decoded are cached too, as they are only decoded when read.
+func (c *CachedClient) get(ctx context.Context, publication string, UUID string) (bool, cachedConcept, error) {
+	key := objectKey(publication, UUID)
+	var etag string
+	cached, ok := c.cache.Get(key)
+	if ok {
+		etag = cached.metadata.ETag
+	}
+
+	getObjectParams := c.client.getObjectInput(publication, UUID)
+	if etag != "" {
+		getObjectParams.IfNoneMatch = aws.String(etag)
+	}
+	found, document, metadata, err := c.client.getDocument(ctx, UUID, getObjectParams)
+	if errors.Is(err, errNotModified) {
+		c.hits.Inc(1)
+		return true, cached, nil
+	}
+	c.misses.Inc(1)
+	if err != nil || !found {
+		c.cache.Remove(key)
+		return found, cachedConcept{}, err
+	}
+	cached = cachedConcept{document: document, metadata: metadata}
+	c.cache.Add(key, cached)
+	return true, cached, nil
+}
+
+// ObjectKey returns the key of the S3 object of a concept.
+func (c *CachedClient) ObjectKey(publication string, UUID string) string {
+	return c.client.ObjectKey(publication, UUID)
+}
+
+func (c *CachedClient) Healthcheck() fthealth.Check {
+	return c.client.Healthcheck()
+}
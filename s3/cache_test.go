@@ -0,0 +1,182 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
+)
+
+type versionedObject struct {
+	etag string
+	tid  string
+	body string
+}
+
+// cacheS3API serves objects which can change between requests, honouring If-None-Match like S3 does.
+type cacheS3API struct {
+	sync.Mutex
+	objects  map[string]versionedObject
+	requests int
+	bodies   int
+}
+
+func (m *cacheS3API) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	m.Lock()
+	defer m.Unlock()
+	m.requests++
+	obj, ok := m.objects[*input.Key]
+	if !ok {
+		return nil, awserr.New("NoSuchKey", "The specified key does not exist.", nil)
+	}
+	if aws.StringValue(input.IfNoneMatch) == obj.etag {
+		return nil, awserr.NewRequestFailure(awserr.New("NotModified", "Not Modified", nil), http.StatusNotModified, "req-1")
+	}
+	m.bodies++
+	return &s3.GetObjectOutput{
+		Body:     io.NopCloser(strings.NewReader(obj.body)),
+		ETag:     aws.String(obj.etag),
+		Metadata: map[string]*string{"Transaction_id": aws.String(obj.tid)},
+	}, nil
+}
+
+func (m *cacheS3API) ListObjectVersionsPagesWithContext(ctx aws.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error {
+	panic("implement me")
+}
+
+func (m *cacheS3API) HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+	panic("implement me")
+}
+
+func TestCachedClient_GetConceptAndTransactionID(t *testing.T) {
+	api := &cacheS3API{
+		objects: map[string]versionedObject{
+			"b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed": {etag: `"v1"`, tid: "tid_1", body: `{"uuid": "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", "prefLabel": "Version 1"}`},
+		},
+	}
+	client := NewCachedClient(&Client{s3: api, bucketName: "cache-test"}, 10, 0)
+
+	found, concept, tid, err := client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Version 1", concept.PrefLabel)
+	assert.Equal(t, "tid_1", tid)
+
+	found, concept, tid, err = client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Version 1", concept.PrefLabel)
+	assert.Equal(t, "tid_1", tid)
+	assert.Equal(t, 2, api.requests, "the cached concept should be validated with S3")
+	assert.Equal(t, 1, api.bodies, "an unchanged concept should not be downloaded again")
+	assert.Equal(t, int64(1), client.hits.Count())
+	assert.Equal(t, int64(1), client.misses.Count())
+
+	api.objects["b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed"] = versionedObject{etag: `"v2"`, tid: "tid_2", body: `{"uuid": "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", "prefLabel": "Version 2"}`}
+	found, concept, tid, err = client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Version 2", concept.PrefLabel)
+	assert.Equal(t, "tid_2", tid)
+	assert.Equal(t, 3, api.requests, "a changed concept should be read by the validating request")
+	assert.Equal(t, int64(2), client.misses.Count())
+
+	delete(api.objects, "b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed")
+	found, _, _, err = client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, 0, client.cache.Len(), "a deleted concept should be evicted")
+}
+
+func TestCachedClient_PublicationsAreCachedSeparately(t *testing.T) {
+	api := &cacheS3API{
+		objects: map[string]versionedObject{
+			"b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed":         {etag: `"v1"`, tid: "tid_1", body: `{"prefLabel": "Internal"}`},
+			"Generic/b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed": {etag: `"v1"`, tid: "tid_2", body: `{"prefLabel": "External"}`},
+		},
+	}
+	client := NewCachedClient(&Client{s3: api, bucketName: "cache-publication-test"}, 10, 0)
+
+	for i := 0; i < 2; i++ {
+		_, concept, _, err := client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+		assert.NoError(t, err)
+		assert.Equal(t, "Internal", concept.PrefLabel)
+
+		_, concept, _, err = client.GetConceptAndTransactionID(context.Background(), "Generic", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+		assert.NoError(t, err)
+		assert.Equal(t, "External", concept.PrefLabel)
+	}
+	assert.Equal(t, 2, api.bodies)
+}
+
+func TestCachedClient_GetConceptDocument(t *testing.T) {
+	document := `{"uuid": "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", "aliases": "Not a list"}`
+	api := &cacheS3API{
+		objects: map[string]versionedObject{
+			"b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed": {etag: `"v1"`, tid: "tid_1", body: document},
+		},
+	}
+	client := NewCachedClient(&Client{s3: api, bucketName: "cache-document-test"}, 10, 0)
+
+	for i := 0; i < 2; i++ {
+		found, actual, tid, err := client.GetConceptDocument(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.JSONEq(t, document, string(actual))
+		assert.Equal(t, "tid_1", tid)
+	}
+
+	found, _, _, err := client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.True(t, found)
+	assert.Equal(t, failure.Validation, failure.KindOf(err), "a document which cannot be decoded should still fail to be read as a concept")
+	assert.Equal(t, 1, api.bodies, "an undecodable document should be cached too")
+}
+
+func TestCachedClient_ConcurrentAggregationsOfTheSameSource(t *testing.T) {
+	api := &cacheS3API{
+		objects: map[string]versionedObject{
+			"b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed": {etag: `"v1"`, tid: "tid_1", body: `{
+				"uuid": "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed",
+				"prefLabel": "Cached",
+				"aliases": ["First alias", "Second alias"],
+				"relationships": [{"uuid": "f7fd05ea-9999-47c0-9be9-c99dd84d0097", "label": "HAS_BROADER"}]
+			}`},
+		},
+	}
+	client := NewCachedClient(&Client{s3: api, bucketName: "cache-concurrent-test"}, 10, 0)
+	// cache the concept, so both aggregations read it from the cache
+	_, _, _, err := client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(aggregation int) {
+			defer wg.Done()
+			_, concept, _, err := client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+			assert.NoError(t, err)
+			// aggregations merge source concepts in place
+			concept.Aliases[0] = fmt.Sprintf("Alias of aggregation %d", aggregation)
+			concept.Aliases = append(concept.Aliases[:1], "Merged alias")
+			concept.Relationships[0].UUID = fmt.Sprintf("aggregation-%d", aggregation)
+		}(i)
+	}
+	wg.Wait()
+
+	_, concept, _, err := client.GetConceptAndTransactionID(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"First alias", "Second alias"}, concept.Aliases, "aggregations should not change the cached concept")
+	assert.Equal(t, "f7fd05ea-9999-47c0-9be9-c99dd84d0097", concept.Relationships[0].UUID)
+	assert.Equal(t, 1, api.bodies)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
@@ -18,8 +19,27 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 
 	ontology "github.com/Financial-Times/cm-graph-ontology/v2"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
+// transactionIDMetadataKey is the user-defined object metadata holding the transaction ID of the last update of a concept.
+const transactionIDMetadataKey = "Transaction_id"
+
+// ConceptStore reads normalised concepts. It is implemented by both Client and CachedClient.
+type ConceptStore interface {
+	GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error)
+	Healthcheck() fthealth.Check
+}
+
+// ObjectMetadata describes the version of a concept read from S3.
+type ObjectMetadata struct {
+	TransactionID string
+	ETag          string
+	LastModified  time.Time
+	VersionID     string
+}
+
 type Client struct {
 	s3         s3API
 	bucketName string
@@ -27,7 +47,7 @@ type Client struct {
 
 type s3API interface {
 	GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error)
-	HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error)
+	ListObjectVersionsPagesWithContext(ctx aws.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error
 	HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error)
 }
 
@@ -72,45 +92,179 @@ func NewClient(bucketName string, awsRegion string) (*Client, error) {
 }
 
 func (c *Client) GetConceptAndTransactionID(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, string, error) {
-	key := getKey(UUID)
-	if publication != "" {
-		key = strings.Join([]string{publication, key}, "/")
+	found, concept, metadata, err := c.GetConcept(ctx, publication, UUID)
+	return found, concept, metadata.TransactionID, err
+}
+
+// ObjectKey returns the key of the S3 object of a concept.
+func (c *Client) ObjectKey(publication string, UUID string) string {
+	return objectKey(publication, UUID)
+}
+
+// GetConcept reads a concept together with the metadata of its S3 object, using a single request.
+func (c *Client) GetConcept(ctx context.Context, publication string, UUID string) (bool, ontology.SourceConcept, ObjectMetadata, error) {
+	return c.getConcept(ctx, UUID, c.getObjectInput(publication, UUID))
+}
+
+// GetConceptVersion reads the given version of a concept from a versioned bucket.
+func (c *Client) GetConceptVersion(ctx context.Context, publication string, UUID string, versionID string) (bool, ontology.SourceConcept, ObjectMetadata, error) {
+	getObjectParams := c.getObjectInput(publication, UUID)
+	getObjectParams.VersionId = aws.String(versionID)
+	return c.getConcept(ctx, UUID, getObjectParams)
+}
+
+// GetConceptAndTransactionIDAsOf reads a concept as it was at the given time, using the latest version of its S3 object created by then.
+// The concept is not found if it did not exist or had been deleted at that time.
+func (c *Client) GetConceptAndTransactionIDAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, ontology.SourceConcept, string, error) {
+	versionID, err := c.versionIDAsOf(ctx, publication, UUID, asOf)
+	if err != nil || versionID == "" {
+		return false, ontology.SourceConcept{}, "", err
+	}
+	found, concept, metadata, err := c.GetConceptVersion(ctx, publication, UUID, versionID)
+	return found, concept, metadata.TransactionID, err
+}
+
+// GetConceptDocument reads the JSON document of a concept as it is stored, without decoding it.
+func (c *Client) GetConceptDocument(ctx context.Context, publication string, UUID string) (bool, []byte, string, error) {
+	found, document, metadata, err := c.getDocument(ctx, UUID, c.getObjectInput(publication, UUID))
+	return found, document, metadata.TransactionID, err
+}
+
+// GetConceptDocumentAsOf reads the JSON document of a concept as it was at the given time, without decoding it.
+func (c *Client) GetConceptDocumentAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (bool, []byte, string, error) {
+	versionID, err := c.versionIDAsOf(ctx, publication, UUID, asOf)
+	if err != nil || versionID == "" {
+		return false, nil, "", err
+	}
+	getObjectParams := c.getObjectInput(publication, UUID)
+	getObjectParams.VersionId = aws.String(versionID)
+	found, document, metadata, err := c.getDocument(ctx, UUID, getObjectParams)
+	return found, document, metadata.TransactionID, err
+}
+
+// versionIDAsOf returns the ID of the version of the concept which was current at the given time,
+// or an empty string if it did not exist or had been deleted at that time.
+func (c *Client) versionIDAsOf(ctx context.Context, publication string, UUID string, asOf time.Time) (string, error) {
+	versionID, err := c.versionAsOf(ctx, objectKey(publication, UUID), asOf)
+	if err != nil {
+		logger.WithError(err).WithUUID(UUID).Error("Error listing concept versions in S3")
+		return "", failure.FromAWS(err)
+	}
+	return versionID, nil
+}
+
+// versionAsOf returns the ID of the version of the object which was current at the given time,
+// or an empty string if the object did not exist or had been deleted at that time.
+func (c *Client) versionAsOf(ctx context.Context, key string, asOf time.Time) (string, error) {
+	var versionID string
+	var versionTime time.Time
+	consider := func(k *string, v *string, lastModified *time.Time, deleted bool) {
+		t := aws.TimeValue(lastModified)
+		// versions are listed from the newest to the oldest, so the first of several versions created at the same time wins
+		if aws.StringValue(k) != key || t.After(asOf) || (!versionTime.IsZero() && !t.After(versionTime)) {
+			return
+		}
+		versionTime = t
+		versionID = aws.StringValue(v)
+		if deleted {
+			versionID = ""
+		}
+	}
+
+	params := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(c.bucketName),
+		Prefix: aws.String(key),
 	}
+	err := c.s3.ListObjectVersionsPagesWithContext(ctx, params, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, v := range page.Versions {
+			consider(v.Key, v.VersionId, v.LastModified, false)
+		}
+		for _, m := range page.DeleteMarkers {
+			consider(m.Key, m.VersionId, m.LastModified, true)
+		}
+		return true
+	})
+	return versionID, err
+}
 
-	getObjectParams := &s3.GetObjectInput{
+func (c *Client) getObjectInput(publication string, UUID string) *s3.GetObjectInput {
+	return &s3.GetObjectInput{
 		Bucket: aws.String(c.bucketName),
-		Key:    aws.String(key),
+		Key:    aws.String(objectKey(publication, UUID)),
 	}
+}
+
+// getConcept reads a concept with the given parameters.
+// errNotModified is returned when the parameters include an ETag which still matches the one of the S3 object.
+func (c *Client) getConcept(ctx context.Context, UUID string, getObjectParams *s3.GetObjectInput) (bool, ontology.SourceConcept, ObjectMetadata, error) {
+	found, document, metadata, err := c.getDocument(ctx, UUID, getObjectParams)
+	if err != nil || !found {
+		return found, ontology.SourceConcept{}, metadata, err
+	}
+	concept, err := decodeConcept(UUID, document)
+	if err != nil {
+		return true, ontology.SourceConcept{}, ObjectMetadata{}, err
+	}
+	return true, concept, metadata, nil
+}
+
+// decodeConcept decodes the JSON document of a concept.
+func decodeConcept(UUID string, document []byte) (ontology.SourceConcept, error) {
+	var concept ontology.SourceConcept
+	if err := json.Unmarshal(document, &concept); err != nil {
+		logger.WithError(err).WithUUID(UUID).Error("Cannot unmarshal object into a concept")
+		return ontology.SourceConcept{}, failure.Wrap(failure.Validation, err)
+	}
+	return concept, nil
+}
+
+// getDocument reads the JSON document of a concept with the given parameters, as it is stored.
+// errNotModified is returned when the parameters include an ETag which still matches the one of the S3 object.
+func (c *Client) getDocument(ctx context.Context, UUID string, getObjectParams *s3.GetObjectInput) (bool, []byte, ObjectMetadata, error) {
+	key := aws.StringValue(getObjectParams.Key)
 
 	resp, err := c.s3.GetObjectWithContext(ctx, getObjectParams)
 	if err != nil {
 		e, ok := err.(awserr.Error)
-		if ok && e.Code() == "NoSuchKey" {
+		if ok && (e.Code() == "NoSuchKey" || e.Code() == "NoSuchVersion") {
 			// NotFound rather than error, so no logging needed.
-			return false, ontology.SourceConcept{}, "", nil
+			return false, nil, ObjectMetadata{}, nil
+		}
+		if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotModified {
+			return true, nil, ObjectMetadata{}, errNotModified
 		}
 		logger.WithError(err).WithUUID(UUID).Error("Error retrieving concept from S3")
-		return false, ontology.SourceConcept{}, "", err
+		return false, nil, ObjectMetadata{}, failure.FromAWS(err)
 	}
 	defer resp.Body.Close()
 
-	getHeadersParams := &s3.HeadObjectInput{
-		Bucket: aws.String(c.bucketName),
-		Key:    aws.String(key),
+	metadata := ObjectMetadata{
+		TransactionID: transactionID(resp.Metadata),
+		ETag:          aws.StringValue(resp.ETag),
+		LastModified:  aws.TimeValue(resp.LastModified),
+		VersionID:     aws.StringValue(resp.VersionId),
+	}
+	if metadata.TransactionID == "" {
+		logger.WithUUID(UUID).Warnf("S3 object %s has no %s metadata", key, transactionIDMetadataKey)
 	}
-	ho, err := c.s3.HeadObjectWithContext(ctx, getHeadersParams)
+
+	document, err := io.ReadAll(resp.Body)
 	if err != nil {
-		logger.WithError(err).WithUUID(UUID).Error("Cannot access S3 head object")
-		return false, ontology.SourceConcept{}, "", err
+		logger.WithError(err).WithUUID(UUID).Error("Error reading concept from S3")
+		return false, nil, ObjectMetadata{}, failure.FromAWS(err)
 	}
-	tid := ho.Metadata["Transaction_id"]
+	return true, document, metadata, nil
+}
 
-	var concept ontology.SourceConcept
-	if err = json.NewDecoder(resp.Body).Decode(&concept); err != nil {
-		logger.WithError(err).WithUUID(UUID).Error("Cannot unmarshal object into a concept")
-		return true, ontology.SourceConcept{}, "", err
+// transactionID returns the transaction ID stored in the object metadata, or an empty string if there is none.
+// The SDK normalises the case of metadata keys, so the key is matched case-insensitively.
+func transactionID(metadata map[string]*string) string {
+	for k, v := range metadata {
+		if strings.EqualFold(k, transactionIDMetadataKey) {
+			return aws.StringValue(v)
+		}
 	}
-	return true, concept, *tid, nil
+	return ""
 }
 
 func (c *Client) Healthcheck() fthealth.Check {
@@ -138,3 +292,11 @@ func (c *Client) Healthcheck() fthealth.Check {
 func getKey(UUID string) string {
 	return strings.Replace(UUID, "-", "/", -1)
 }
+
+func objectKey(publication string, UUID string) string {
+	key := getKey(UUID)
+	if publication != "" {
+		key = strings.Join([]string{publication, key}, "/")
+	}
+	return key
+}
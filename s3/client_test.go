@@ -3,15 +3,21 @@ package s3
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/Financial-Times/cm-graph-ontology/v2/transform"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
 func TestClient_GetConceptAndTransactionID(t *testing.T) {
@@ -54,6 +60,81 @@ func TestClient_GetConceptAndTransactionID(t *testing.T) {
 	}
 }
 
+func TestClient_GetConcept_Metadata(t *testing.T) {
+	lastModified := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		tid      string
+		expected ObjectMetadata
+	}{
+		"With transaction ID": {
+			tid:      "tid_test",
+			expected: ObjectMetadata{TransactionID: "tid_test", ETag: `"etag"`, LastModified: lastModified},
+		},
+		"Without transaction ID": {
+			expected: ObjectMetadata{ETag: `"etag"`, LastModified: lastModified},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := &Client{
+				s3: &mockS3API{
+					t:                  t,
+					testBucket:         "testBucket",
+					testKey:            "testKey",
+					testTID:            tc.tid,
+					testETag:           `"etag"`,
+					testLastModified:   lastModified,
+					testConceptFixture: "testdata/test-concept.json",
+				},
+				bucketName: "testBucket",
+			}
+
+			found, _, metadata, err := client.GetConcept(context.Background(), "", "testKey")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !found {
+				t.Error("expected s3 to have the concept")
+			}
+			if metadata != tc.expected {
+				t.Errorf("expect metadata %+v, got %+v", tc.expected, metadata)
+			}
+		})
+	}
+}
+
+func TestClient_ObjectKey(t *testing.T) {
+	c := &Client{bucketName: "test-bucket"}
+	testCases := map[string]string{
+		"":        "f3633e04/2ee3/48ce/8081/37734dab3fdc",
+		"Generic": "Generic/f3633e04/2ee3/48ce/8081/37734dab3fdc",
+	}
+	for publication, expected := range testCases {
+		if key := c.ObjectKey(publication, "f3633e04-2ee3-48ce-8081-37734dab3fdc"); key != expected {
+			t.Errorf("expect key %s for publication %q, got %s", expected, publication, key)
+		}
+	}
+}
+
+func TestTransactionID(t *testing.T) {
+	testCases := map[string]struct {
+		metadata map[string]*string
+		expected string
+	}{
+		"Missing metadata": {},
+		"Missing key":      {metadata: map[string]*string{"Other": aws.String("value")}, expected: ""},
+		"Nil value":        {metadata: map[string]*string{"Transaction_id": nil}, expected: ""},
+		"Different case":   {metadata: map[string]*string{"Transaction-Id": aws.String("tid_1"), "transaction_id": aws.String("tid_2")}, expected: "tid_2"},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if actual := transactionID(tc.metadata); actual != tc.expected {
+				t.Errorf("expect tid %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
 func readOldConceptFixture(t *testing.T, filename string) transform.OldConcept {
 	t.Helper()
 	f, err := os.Open(filename)
@@ -73,6 +154,8 @@ type mockS3API struct {
 	testBucket         string
 	testKey            string
 	testTID            string
+	testETag           string
+	testLastModified   time.Time
 	testConceptFixture string
 }
 
@@ -96,32 +179,205 @@ func (m *mockS3API) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInp
 		m.t.Fatal(err)
 	}
 
+	output := &s3.GetObjectOutput{
+		Body:         conceptFile,
+		ETag:         aws.String(m.testETag),
+		LastModified: aws.Time(m.testLastModified),
+	}
+	if m.testTID != "" {
+		output.Metadata = map[string]*string{
+			"Transaction_id": aws.String(m.testTID),
+		}
+	}
+	return output, nil
+}
+
+func (m *mockS3API) ListObjectVersionsPagesWithContext(ctx aws.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error {
+	panic("implement me")
+}
+
+func (m *mockS3API) HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+	panic("implement me")
+}
+
+// versionedS3API serves the versions of objects in a versioned bucket, listing them one per page.
+type versionedS3API struct {
+	versions      []*s3.ObjectVersion
+	deleteMarkers []*s3.DeleteMarkerEntry
+	bodies        map[string]string
+	listErr       error
+}
+
+func (m *versionedS3API) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	body, ok := m.bodies[aws.StringValue(input.VersionId)]
+	if !ok {
+		return nil, awserr.New("NoSuchVersion", "The specified version does not exist.", nil)
+	}
 	return &s3.GetObjectOutput{
-		Body: conceptFile,
+		Body:      io.NopCloser(strings.NewReader(body)),
+		Metadata:  map[string]*string{"Transaction_id": aws.String("tid_" + aws.StringValue(input.VersionId))},
+		VersionId: input.VersionId,
 	}, nil
 }
 
-func (m *mockS3API) HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
-	m.t.Helper()
-	if input.Bucket == nil {
-		m.t.Fatal("expect bucket to not be nil")
+func (m *versionedS3API) ListObjectVersionsPagesWithContext(ctx aws.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error {
+	if m.listErr != nil {
+		return m.listErr
 	}
-	if e, a := m.testBucket, *input.Bucket; e != a {
-		m.t.Errorf("expect bucket %v, got %v", e, a)
+	var pages []*s3.ListObjectVersionsOutput
+	for _, v := range m.versions {
+		if strings.HasPrefix(aws.StringValue(v.Key), aws.StringValue(input.Prefix)) {
+			pages = append(pages, &s3.ListObjectVersionsOutput{Versions: []*s3.ObjectVersion{v}})
+		}
 	}
-	if input.Key == nil {
-		m.t.Fatal("expect key to not be nil")
+	for _, d := range m.deleteMarkers {
+		if strings.HasPrefix(aws.StringValue(d.Key), aws.StringValue(input.Prefix)) {
+			pages = append(pages, &s3.ListObjectVersionsOutput{DeleteMarkers: []*s3.DeleteMarkerEntry{d}})
+		}
 	}
-	if e, a := m.testKey, *input.Key; e != a {
-		m.t.Errorf("expect key %v, got %v", e, a)
+	for i, page := range pages {
+		if !fn(page, i == len(pages)-1) {
+			break
+		}
 	}
-	return &s3.HeadObjectOutput{
-		Metadata: map[string]*string{
-			"Transaction_id": aws.String(m.testTID),
-		},
-	}, nil
+	return nil
 }
 
-func (m *mockS3API) HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+func (m *versionedS3API) HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
 	panic("implement me")
 }
+
+func TestClient_GetConceptAndTransactionIDAsOf(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC)
+	}
+	key := "b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed"
+	api := &versionedS3API{
+		versions: []*s3.ObjectVersion{
+			{Key: aws.String(key), VersionId: aws.String("v3"), LastModified: aws.Time(day(5))},
+			{Key: aws.String(key), VersionId: aws.String("v2"), LastModified: aws.Time(day(3))},
+			{Key: aws.String(key), VersionId: aws.String("v1"), LastModified: aws.Time(day(1))},
+			{Key: aws.String(key + "/other"), VersionId: aws.String("other"), LastModified: aws.Time(day(4))},
+		},
+		deleteMarkers: []*s3.DeleteMarkerEntry{
+			{Key: aws.String(key), VersionId: aws.String("deleted"), LastModified: aws.Time(day(4))},
+		},
+		bodies: map[string]string{
+			"v1": `{"prefLabel": "Version 1"}`,
+			"v2": `{"prefLabel": "Version 2"}`,
+			"v3": `{"prefLabel": "Version 3"}`,
+		},
+	}
+	client := &Client{s3: api, bucketName: "testBucket"}
+
+	testCases := map[string]struct {
+		asOf          time.Time
+		expectedFound bool
+		expectedLabel string
+		expectedTID   string
+	}{
+		"Before the concept existed": {
+			asOf: day(1).Add(-time.Second),
+		},
+		"When the first version was created": {
+			asOf:          day(1),
+			expectedFound: true,
+			expectedLabel: "Version 1",
+			expectedTID:   "tid_v1",
+		},
+		"Between versions": {
+			asOf:          day(3).Add(time.Hour),
+			expectedFound: true,
+			expectedLabel: "Version 2",
+			expectedTID:   "tid_v2",
+		},
+		"While the concept was deleted": {
+			asOf: day(4).Add(time.Hour),
+		},
+		"After it was recreated": {
+			asOf:          day(10),
+			expectedFound: true,
+			expectedLabel: "Version 3",
+			expectedTID:   "tid_v3",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			found, concept, tid, err := client.GetConceptAndTransactionIDAsOf(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", tc.asOf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != tc.expectedFound {
+				t.Errorf("expect found %v, got %v", tc.expectedFound, found)
+			}
+			if concept.PrefLabel != tc.expectedLabel {
+				t.Errorf("expect label %q, got %q", tc.expectedLabel, concept.PrefLabel)
+			}
+			if tid != tc.expectedTID {
+				t.Errorf("expect tid %q, got %q", tc.expectedTID, tid)
+			}
+		})
+	}
+}
+
+func TestClient_GetConceptDocumentAsOf(t *testing.T) {
+	key := "b4ddd5a5/0b6c/4dc2/bb75/3eb40c1b05ed"
+	api := &versionedS3API{
+		versions: []*s3.ObjectVersion{
+			{Key: aws.String(key), VersionId: aws.String("v2"), LastModified: aws.Time(time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC))},
+			{Key: aws.String(key), VersionId: aws.String("v1"), LastModified: aws.Time(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))},
+		},
+		bodies: map[string]string{
+			"v1": `{"prefLabel": "Version 1", "aliases": "Not a list"}`,
+			"v2": `{"prefLabel": "Version 2"}`,
+		},
+	}
+	client := &Client{s3: api, bucketName: "testBucket"}
+
+	found, document, tid, err := client.GetConceptDocumentAsOf(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("expected the first version to be found")
+	}
+	if string(document) != api.bodies["v1"] {
+		t.Errorf("expect the stored document %s, got %s", api.bodies["v1"], document)
+	}
+	if tid != "tid_v1" {
+		t.Errorf("expect tid %q, got %q", "tid_v1", tid)
+	}
+
+	found, _, _, err = client.GetConceptDocumentAsOf(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("expected no document before the concept existed")
+	}
+}
+
+func TestClient_GetConceptAndTransactionIDAsOf_ListError(t *testing.T) {
+	api := &versionedS3API{listErr: awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), 403, "req-1")}
+	client := &Client{s3: api, bucketName: "testBucket"}
+
+	_, _, _, err := client.GetConceptAndTransactionIDAsOf(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", time.Now())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if kind := failure.KindOf(err); kind != failure.Unavailable {
+		t.Errorf("expect kind %v, got %v", failure.Unavailable, kind)
+	}
+}
+
+func TestClient_GetConceptVersion_NoSuchVersion(t *testing.T) {
+	client := &Client{s3: &versionedS3API{}, bucketName: "testBucket"}
+
+	found, _, _, err := client.GetConceptVersion(context.Background(), "", "b4ddd5a5-0b6c-4dc2-bb75-3eb40c1b05ed", "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("expected the version not to be found")
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sns"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
 type PublishAPI interface {
@@ -53,7 +55,7 @@ func (c *client) PublishEvents(ctx context.Context, events []Event) error {
 	for i, ev := range events {
 		evData, err := json.Marshal(ev)
 		if err != nil {
-			return fmt.Errorf("marshaling concept %q: %w", ev.ConceptUUID, err)
+			return failure.Wrap(failure.Validation, fmt.Errorf("marshaling concept %q: %w", ev.ConceptUUID, err))
 		}
 
 		entry := &sns.PublishBatchRequestEntry{
@@ -69,14 +71,19 @@ func (c *client) PublishEvents(ctx context.Context, events []Event) error {
 		PublishBatchRequestEntries: entries,
 	})
 	if err != nil {
-		return err
+		return failure.FromAWS(err)
 	}
 
 	errs := []error{}
+	// the batch can be retried unless every entry failed because of the request itself
+	kind := failure.Permanent
 	for _, o := range output.Failed {
 		err := fmt.Errorf("publishing %s event failed: %s", *o.Id, *o.Code)
 		errs = append(errs, err)
+		if !aws.BoolValue(o.SenderFault) {
+			kind = failure.Transient
+		}
 	}
 
-	return errors.Join(errs...)
+	return failure.Wrap(kind, errors.Join(errs...))
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/sns"
+
+	"github.com/Financial-Times/aggregate-concept-transformer/failure"
 )
 
 type MockPublishAPI func(ctx aws.Context, input *sns.PublishBatchInput, opts ...request.Option) (*sns.PublishBatchOutput, error)
@@ -33,6 +35,7 @@ func TestPublishEvents(t *testing.T) {
 		getSNSSvc func(t *testing.T) PublishAPI
 		events    []Event
 		wanterr   error
+		wantkind  failure.Kind
 	}{
 		"Successfully": {
 			getSNSSvc: func(t *testing.T) PublishAPI {
@@ -51,7 +54,8 @@ func TestPublishEvents(t *testing.T) {
 					return nil, ErrNotFound
 				})
 			},
-			wanterr: ErrNotFound,
+			wanterr:  ErrNotFound,
+			wantkind: failure.Unavailable,
 			events: []Event{
 				{},
 			},
@@ -158,6 +162,28 @@ func TestPublishEvents(t *testing.T) {
 				},
 			},
 		},
+		"SenderFault": {
+			getSNSSvc: func(t *testing.T) PublishAPI {
+				return MockPublishAPI(func(ctx aws.Context, input *sns.PublishBatchInput, opts ...request.Option) (*sns.PublishBatchOutput, error) {
+					return &sns.PublishBatchOutput{
+						Failed: []*sns.BatchResultErrorEntry{
+							{
+								Id:          aws.String("28090964-9997-4bc2-9638-7a11135aaff9_0"),
+								Code:        aws.String("some-aws-error-code"),
+								SenderFault: aws.Bool(true),
+							},
+						},
+					}, nil
+				})
+			},
+			wanterr:  fmt.Errorf("publishing %s event failed: %s", "28090964-9997-4bc2-9638-7a11135aaff9_0", "some-aws-error-code"),
+			wantkind: failure.Permanent,
+			events: []Event{
+				{
+					ConceptUUID: "28090964-9997-4bc2-9638-7a11135aaff9",
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -182,6 +208,9 @@ func TestPublishEvents(t *testing.T) {
 			if err.Error() != test.wanterr.Error() {
 				t.Fatalf("got: %s, want: %s", err, test.wanterr)
 			}
+			if kind := failure.KindOf(err); kind != test.wantkind {
+				t.Fatalf("got kind: %s, want: %s", kind, test.wantkind)
+			}
 		})
 	}
 }
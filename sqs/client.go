@@ -5,35 +5,54 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	fthealth "github.com/Financial-Times/go-fthealth/v1_1"
 	"github.com/Financial-Times/go-logger"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
 )
 
+// MaxNumberOfMessages is the most messages SQS returns from a single receive.
+const MaxNumberOfMessages = 10
+
 var keyMatcher = regexp.MustCompile("[0-9a-f]{8}/[0-9a-f]{4}/[0-9a-f]{4}/[0-9a-f]{4}/[0-9a-f]{12}")
 
 type Client interface {
-	ListenAndServeQueue(ctx context.Context) []ConceptUpdate
+	ListenAndServeQueue(ctx context.Context, maxMessages int) []ConceptUpdate
 	RemoveMessageFromQueue(ctx context.Context, receiptHandle *string) error
+	DeadLetterMessage(ctx context.Context, update ConceptUpdate, transactionID string, reason string) (bool, error)
+	QuarantineMessage(ctx context.Context, update ConceptUpdate, transactionID string, reason string) error
+	ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int) error
 	Healthcheck() fthealth.Check
 }
 
+type sqsAPI interface {
+	ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessageWithContext(ctx aws.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error)
+	SendMessageWithContext(ctx aws.Context, input *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error)
+	ChangeMessageVisibilityWithContext(ctx aws.Context, input *sqs.ChangeMessageVisibilityInput, opts ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error)
+	GetQueueAttributes(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error)
+}
+
 type NotificationClient struct {
-	sqs          *sqs.SQS
-	listenParams sqs.ReceiveMessageInput
-	queueUrl     string
+	sqs                sqsAPI
+	listenParams       sqs.ReceiveMessageInput
+	queueUrl           string
+	deadLetterQueueUrl string
+	maxReceiveCount    int
 }
 
-func NewClient(awsRegion, queueURL, endpoint string, messagesToProcess, visibilityTimeout, waitTime int) (Client, error) {
+func NewClient(awsRegion, queueURL, deadLetterQueueURL, endpoint string, messagesToProcess, visibilityTimeout, waitTime, maxReceiveCount int) (Client, error) {
 	listenParams := sqs.ReceiveMessageInput{
 		QueueUrl:            aws.String(queueURL),
 		MaxNumberOfMessages: aws.Int64(int64(messagesToProcess)),
 		VisibilityTimeout:   aws.Int64(int64(visibilityTimeout)),
 		WaitTimeSeconds:     aws.Int64(int64(waitTime)),
+		AttributeNames:      []*string{aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount)},
 	}
 
 	conf := &aws.Config{
@@ -56,16 +75,24 @@ func NewClient(awsRegion, queueURL, endpoint string, messagesToProcess, visibili
 
 	client := sqs.New(sess)
 	return &NotificationClient{
-		sqs:          client,
-		listenParams: listenParams,
-		queueUrl:     queueURL,
+		sqs:                client,
+		listenParams:       listenParams,
+		queueUrl:           queueURL,
+		deadLetterQueueUrl: deadLetterQueueURL,
+		maxReceiveCount:    maxReceiveCount,
 	}, err
 }
 
-func (c *NotificationClient) ListenAndServeQueue(ctx context.Context) []ConceptUpdate {
-	messages, err := c.sqs.ReceiveMessageWithContext(ctx, &c.listenParams)
+// ListenAndServeQueue receives at most maxMessages messages, and never more than the client was configured to receive at once.
+func (c *NotificationClient) ListenAndServeQueue(ctx context.Context, maxMessages int) []ConceptUpdate {
+	listenParams := c.listenParams
+	if int64(maxMessages) < *listenParams.MaxNumberOfMessages {
+		listenParams.MaxNumberOfMessages = aws.Int64(int64(maxMessages))
+	}
+	messages, err := c.sqs.ReceiveMessageWithContext(ctx, &listenParams)
 	if err != nil {
 		logger.WithError(err).Error("Error whilst listening for messages")
+		return []ConceptUpdate{}
 	}
 	return getNotificationsFromMessages(messages.Messages)
 }
@@ -82,6 +109,80 @@ func (c *NotificationClient) RemoveMessageFromQueue(ctx context.Context, receipt
 	return nil
 }
 
+// DeadLetterMessage moves the message to the dead-letter queue once it has been received more than maxReceiveCount times.
+// It reports whether the message was moved; messages below the threshold are left on the queue to be retried.
+func (c *NotificationClient) DeadLetterMessage(ctx context.Context, update ConceptUpdate, transactionID string, reason string) (bool, error) {
+	if c.deadLetterQueueUrl == "" || update.ReceiveCount <= c.maxReceiveCount {
+		return false, nil
+	}
+	return c.moveToDeadLetterQueue(ctx, update, transactionID, reason)
+}
+
+// QuarantineMessage takes a message which can never be processed off the queue, regardless of how many times it was received.
+// The message is moved to the dead-letter queue when one is configured, otherwise it is only deleted.
+func (c *NotificationClient) QuarantineMessage(ctx context.Context, update ConceptUpdate, transactionID string, reason string) error {
+	if c.deadLetterQueueUrl == "" {
+		return c.RemoveMessageFromQueue(ctx, update.ReceiptHandle)
+	}
+	_, err := c.moveToDeadLetterQueue(ctx, update, transactionID, reason)
+	return err
+}
+
+// ChangeMessageVisibility sets how many seconds the message stays hidden from other consumers before it is received again.
+func (c *NotificationClient) ChangeMessageVisibility(ctx context.Context, receiptHandle *string, visibilityTimeout int) error {
+	visibilityParams := sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(c.queueUrl),
+		ReceiptHandle:     receiptHandle,
+		VisibilityTimeout: aws.Int64(int64(visibilityTimeout)),
+	}
+	if _, err := c.sqs.ChangeMessageVisibilityWithContext(ctx, &visibilityParams); err != nil {
+		logger.WithError(err).Error("Error changing message visibility in SQS")
+		return err
+	}
+	return nil
+}
+
+// moveToDeadLetterQueue sends the message to the dead-letter queue and deletes it from the main queue.
+// The original body is kept so the message can be replayed, while the failure details are attached as message attributes.
+// It reports whether the message reached the dead-letter queue, even if it could not be deleted afterwards.
+func (c *NotificationClient) moveToDeadLetterQueue(ctx context.Context, update ConceptUpdate, transactionID string, reason string) (bool, error) {
+	attributes := map[string]*sqs.MessageAttributeValue{
+		"ReceiveCount": {
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(strconv.Itoa(update.ReceiveCount)),
+		},
+	}
+	// SQS rejects attributes with empty values, so only the known details are attached.
+	for name, value := range map[string]string{
+		"UUID":          update.UUID,
+		"Bookmark":      update.Bookmark,
+		"TransactionID": transactionID,
+		"FailureReason": reason,
+	} {
+		if value == "" {
+			continue
+		}
+		attributes[name] = &sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	sendParams := sqs.SendMessageInput{
+		QueueUrl:          aws.String(c.deadLetterQueueUrl),
+		MessageBody:       aws.String(update.Body),
+		MessageAttributes: attributes,
+	}
+	if _, err := c.sqs.SendMessageWithContext(ctx, &sendParams); err != nil {
+		logger.WithError(err).WithUUID(update.UUID).Error("Error sending message to the dead-letter queue")
+		return false, err
+	}
+	if err := c.RemoveMessageFromQueue(ctx, update.ReceiptHandle); err != nil {
+		return true, fmt.Errorf("message sent to the dead-letter queue but not removed from the main queue: %w", err)
+	}
+	return true, nil
+}
+
 func getNotificationsFromMessages(messages []*sqs.Message) []ConceptUpdate {
 
 	notifications := []ConceptUpdate{}
@@ -118,12 +219,27 @@ func getNotificationsFromMessages(messages []*sqs.Message) []ConceptUpdate {
 			UUID:          strings.Replace(key, "/", "-", -1),
 			Bookmark:      bookmark, //no need to verify via regex, because neo4j might change the pattern..
 			ReceiptHandle: receiptHandle,
+			ReceiveCount:  getReceiveCount(message),
+			Body:          *message.Body,
 		})
 	}
 
 	return notifications
 }
 
+func getReceiveCount(message *sqs.Message) int {
+	count, ok := message.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]
+	if !ok || count == nil {
+		return 0
+	}
+	receiveCount, err := strconv.Atoi(*count)
+	if err != nil {
+		logger.WithError(err).Warn("Invalid ApproximateReceiveCount attribute on SQS message")
+		return 0
+	}
+	return receiveCount
+}
+
 func (c *NotificationClient) Healthcheck() fthealth.Check {
 	return fthealth.Check{
 		BusinessImpact:   "Editorial updates of concepts will not be written into UPP",
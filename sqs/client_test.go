@@ -0,0 +1,220 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Financial-Times/go-logger"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMessageBody = `{"Message":"{\"Records\":[{\"s3\":{\"object\":{\"key\":\"f8024a12/2d71/4f0e/996d/bcbc07df3921\"}},\"bookmark\":\"FB:kcwQ\"}]}"}`
+
+func init() {
+	logger.InitLogger("test-aggregate-concept-transformer", "panic")
+}
+
+func TestGetNotificationsFromMessages(t *testing.T) {
+	messages := []*sqs.Message{
+		{
+			Body:          aws.String(testMessageBody),
+			ReceiptHandle: aws.String("receipt-1"),
+			Attributes: map[string]*string{
+				sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String("3"),
+			},
+		},
+		{
+			Body:          aws.String(testMessageBody),
+			ReceiptHandle: aws.String("receipt-2"),
+		},
+	}
+
+	notifications := getNotificationsFromMessages(messages)
+	assert.Len(t, notifications, 2)
+	assert.Equal(t, "f8024a12-2d71-4f0e-996d-bcbc07df3921", notifications[0].UUID)
+	assert.Equal(t, "FB:kcwQ", notifications[0].Bookmark)
+	assert.Equal(t, 3, notifications[0].ReceiveCount)
+	assert.Equal(t, testMessageBody, notifications[0].Body)
+	assert.Equal(t, 0, notifications[1].ReceiveCount)
+}
+
+func TestNotificationClient_ListenAndServeQueue(t *testing.T) {
+	api := &mockSQSAPI{}
+	client := &NotificationClient{
+		sqs: api,
+		listenParams: sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String("queue"),
+			MaxNumberOfMessages: aws.Int64(5),
+		},
+	}
+
+	notifications := client.ListenAndServeQueue(context.Background(), 3)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, int64(3), *api.received.MaxNumberOfMessages)
+
+	client.ListenAndServeQueue(context.Background(), MaxNumberOfMessages)
+	assert.Equal(t, int64(5), *api.received.MaxNumberOfMessages)
+	assert.Equal(t, int64(5), *client.listenParams.MaxNumberOfMessages)
+}
+
+func TestNotificationClient_DeadLetterMessage(t *testing.T) {
+	testCases := map[string]struct {
+		deadLetterQueueURL string
+		receiveCount       int
+		sendErr            error
+		deleteErr          error
+		expectMoved        bool
+		expectErr          bool
+		expectSent         bool
+	}{
+		"Below max receive count": {
+			deadLetterQueueURL: "dlq",
+			receiveCount:       5,
+		},
+		"No dead-letter queue configured": {
+			receiveCount: 6,
+		},
+		"Above max receive count": {
+			deadLetterQueueURL: "dlq",
+			receiveCount:       6,
+			expectMoved:        true,
+			expectSent:         true,
+		},
+		"Send fails": {
+			deadLetterQueueURL: "dlq",
+			receiveCount:       6,
+			sendErr:            errors.New("send failed"),
+			expectErr:          true,
+			expectSent:         true,
+		},
+		"Delete fails": {
+			deadLetterQueueURL: "dlq",
+			receiveCount:       6,
+			deleteErr:          errors.New("delete failed"),
+			expectMoved:        true,
+			expectErr:          true,
+			expectSent:         true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			api := &mockSQSAPI{sendErr: tc.sendErr, deleteErr: tc.deleteErr}
+			client := &NotificationClient{
+				sqs:                api,
+				queueUrl:           "queue",
+				deadLetterQueueUrl: tc.deadLetterQueueURL,
+				maxReceiveCount:    5,
+			}
+			update := ConceptUpdate{
+				UUID:          "f8024a12-2d71-4f0e-996d-bcbc07df3921",
+				Bookmark:      "FB:kcwQ",
+				ReceiptHandle: aws.String("receipt-1"),
+				ReceiveCount:  tc.receiveCount,
+				Body:          testMessageBody,
+			}
+
+			moved, err := client.DeadLetterMessage(context.Background(), update, "tid_123", "more than 1 primary authority")
+			assert.Equal(t, tc.expectMoved, moved)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if !tc.expectSent {
+				assert.Nil(t, api.sent)
+				return
+			}
+
+			assert.Equal(t, "dlq", *api.sent.QueueUrl)
+			assert.Equal(t, testMessageBody, *api.sent.MessageBody)
+			assert.Equal(t, "f8024a12-2d71-4f0e-996d-bcbc07df3921", *api.sent.MessageAttributes["UUID"].StringValue)
+			assert.Equal(t, "FB:kcwQ", *api.sent.MessageAttributes["Bookmark"].StringValue)
+			assert.Equal(t, "tid_123", *api.sent.MessageAttributes["TransactionID"].StringValue)
+			assert.Equal(t, "more than 1 primary authority", *api.sent.MessageAttributes["FailureReason"].StringValue)
+			assert.Equal(t, "6", *api.sent.MessageAttributes["ReceiveCount"].StringValue)
+			if tc.sendErr == nil {
+				assert.Equal(t, "receipt-1", *api.deleted.ReceiptHandle)
+			}
+		})
+	}
+}
+
+func TestNotificationClient_QuarantineMessage(t *testing.T) {
+	update := ConceptUpdate{
+		UUID:          "f8024a12-2d71-4f0e-996d-bcbc07df3921",
+		ReceiptHandle: aws.String("receipt-1"),
+		ReceiveCount:  1,
+		Body:          testMessageBody,
+	}
+
+	api := &mockSQSAPI{}
+	client := &NotificationClient{sqs: api, queueUrl: "queue", deadLetterQueueUrl: "dlq", maxReceiveCount: 5}
+	err := client.QuarantineMessage(context.Background(), update, "tid_123", "no concordances provided")
+	assert.NoError(t, err)
+	assert.Equal(t, "dlq", *api.sent.QueueUrl)
+	assert.Equal(t, "no concordances provided", *api.sent.MessageAttributes["FailureReason"].StringValue)
+	assert.Equal(t, "receipt-1", *api.deleted.ReceiptHandle)
+
+	api = &mockSQSAPI{}
+	client = &NotificationClient{sqs: api, queueUrl: "queue", maxReceiveCount: 5}
+	err = client.QuarantineMessage(context.Background(), update, "tid_123", "no concordances provided")
+	assert.NoError(t, err)
+	assert.Nil(t, api.sent)
+	assert.Equal(t, "queue", *api.deleted.QueueUrl)
+}
+
+func TestNotificationClient_ChangeMessageVisibility(t *testing.T) {
+	api := &mockSQSAPI{}
+	client := &NotificationClient{sqs: api, queueUrl: "queue"}
+
+	err := client.ChangeMessageVisibility(context.Background(), aws.String("receipt-1"), 40)
+	assert.NoError(t, err)
+	assert.Equal(t, "queue", *api.visibility.QueueUrl)
+	assert.Equal(t, "receipt-1", *api.visibility.ReceiptHandle)
+	assert.Equal(t, int64(40), *api.visibility.VisibilityTimeout)
+
+	api.visibilityErr = errors.New("receipt handle is invalid")
+	err = client.ChangeMessageVisibility(context.Background(), aws.String("receipt-1"), 40)
+	assert.EqualError(t, err, "receipt handle is invalid")
+}
+
+type mockSQSAPI struct {
+	sendErr       error
+	deleteErr     error
+	visibilityErr error
+	sent          *sqs.SendMessageInput
+	deleted       *sqs.DeleteMessageInput
+	visibility    *sqs.ChangeMessageVisibilityInput
+	received      *sqs.ReceiveMessageInput
+}
+
+func (m *mockSQSAPI) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	m.received = input
+	return &sqs.ReceiveMessageOutput{
+		Messages: []*sqs.Message{{Body: aws.String(testMessageBody), ReceiptHandle: aws.String("receipt-1")}},
+	}, nil
+}
+
+func (m *mockSQSAPI) DeleteMessageWithContext(ctx aws.Context, input *sqs.DeleteMessageInput, opts ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	m.deleted = input
+	return &sqs.DeleteMessageOutput{}, m.deleteErr
+}
+
+func (m *mockSQSAPI) SendMessageWithContext(ctx aws.Context, input *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	m.sent = input
+	return &sqs.SendMessageOutput{}, m.sendErr
+}
+
+func (m *mockSQSAPI) ChangeMessageVisibilityWithContext(ctx aws.Context, input *sqs.ChangeMessageVisibilityInput, opts ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	m.visibility = input
+	return &sqs.ChangeMessageVisibilityOutput{}, m.visibilityErr
+}
+
+func (m *mockSQSAPI) GetQueueAttributes(input *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{}, nil
+}
@@ -4,6 +4,10 @@ type ConceptUpdate struct {
 	UUID          string
 	Bookmark      string
 	ReceiptHandle *string
+	// ReceiveCount is the number of times SQS has delivered the message, including this one.
+	ReceiveCount int
+	// Body is the raw SQS message body, kept so the message can be replayed from the dead-letter queue.
+	Body string
 }
 
 // SQS Message Format